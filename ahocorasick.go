@@ -0,0 +1,212 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import "sort"
+
+type acEdge struct {
+	ch uint8
+	to int32
+}
+
+type acNode struct {
+	edges   []acEdge // 按 ch 升序
+	fail    int32
+	dict    int32 // 失配链上下一个有输出的节点, -1 为无
+	pattern int32 // 在此结束的模式, -1 为无
+}
+
+// ACMatch : pattern index and byte range [Offset, End) in the text
+type ACMatch struct {
+	Pattern int
+	Offset  int
+	End     int
+}
+
+// AhoCorasick : multi-pattern matcher, finds all patterns in one pass over the text
+type AhoCorasick struct {
+	nodes   []acNode
+	root    [256]int32 // 根节点的稠密转移表
+	size    []int
+	longest int
+	fold    bool
+}
+
+// NewAhoCorasick : caseInsensitive folds ASCII letters by ToLower.
+// Empty patterns never match, duplicated patterns are reported by the first index
+func NewAhoCorasick(patterns []string, caseInsensitive bool) *AhoCorasick {
+	ac := &AhoCorasick{fold: caseInsensitive, size: make([]int, len(patterns))}
+	ac.nodes = append(ac.nodes, acNode{dict: -1, pattern: -1})
+	for id, pattern := range patterns {
+		ac.size[id] = len(pattern)
+		ac.longest = max(ac.longest, len(pattern))
+		if len(pattern) == 0 {
+			continue
+		}
+		state := int32(0)
+		for i := 0; i < len(pattern); i++ {
+			state = ac.insert(state, ac.byteOf(pattern[i]))
+		}
+		if ac.nodes[state].pattern < 0 {
+			ac.nodes[state].pattern = int32(id)
+		}
+	}
+	ac.build()
+	return ac
+}
+
+//go:nosplit
+func (ac *AhoCorasick) byteOf(ch uint8) uint8 {
+	if ac.fold {
+		return ToLower(ch)
+	}
+	return ch
+}
+
+//go:nosplit
+func (ac *AhoCorasick) edge(state int32, ch uint8) int32 {
+	for _, e := range ac.nodes[state].edges {
+		if e.ch == ch {
+			return e.to
+		}
+		if e.ch > ch {
+			break
+		}
+	}
+	return -1
+}
+
+func (ac *AhoCorasick) insert(state int32, ch uint8) int32 {
+	if to := ac.edge(state, ch); to >= 0 {
+		return to
+	}
+	to := int32(len(ac.nodes))
+	ac.nodes = append(ac.nodes, acNode{dict: -1, pattern: -1})
+	edges := ac.nodes[state].edges
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].ch > ch })
+	edges = append(edges, acEdge{})
+	copy(edges[i+1:], edges[i:])
+	edges[i] = acEdge{ch, to}
+	ac.nodes[state].edges = edges
+	return to
+}
+
+// build : fail and dict links in BFS order
+func (ac *AhoCorasick) build() {
+	queue := make([]int32, 0, len(ac.nodes))
+	for _, e := range ac.nodes[0].edges {
+		ac.root[e.ch] = e.to
+		queue = append(queue, e.to)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, e := range ac.nodes[state].edges {
+			fail := ac.nodes[state].fail
+			for {
+				if to := ac.edge(fail, e.ch); to >= 0 {
+					fail = to
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = ac.nodes[fail].fail
+			}
+			child := &ac.nodes[e.to]
+			child.fail = fail
+			if ac.nodes[fail].pattern >= 0 {
+				child.dict = fail
+			} else {
+				child.dict = ac.nodes[fail].dict
+			}
+			queue = append(queue, e.to)
+		}
+	}
+}
+
+//go:nosplit
+func (ac *AhoCorasick) step(state int32, ch uint8) int32 {
+	for state != 0 {
+		if to := ac.edge(state, ch); to >= 0 {
+			return to
+		}
+		state = ac.nodes[state].fail
+	}
+	return ac.root[ch]
+}
+
+// FindAll : every match, overlapping ones too, in order of match end.
+// handler gets pattern index and start offset, if handler return true, then match end.
+func (ac *AhoCorasick) FindAll(text string, handler func(pattern, offset int) bool) {
+	if handler == nil {
+		return
+	}
+	state := int32(0)
+	for i := 0; i < len(text); i++ {
+		state = ac.step(state, ac.byteOf(text[i]))
+		out := state
+		if ac.nodes[out].pattern < 0 {
+			out = ac.nodes[out].dict
+		}
+		for ; out > 0; out = ac.nodes[out].dict {
+			pattern := int(ac.nodes[out].pattern)
+			if handler(pattern, i+1-ac.size[pattern]) {
+				return
+			}
+		}
+	}
+}
+
+// LeftmostLongest : matches not overlapping each other, the leftmost first,
+// the longest of those starting at the same offset, in one pass that goes back at most
+// the longest pattern length after each match.
+// handler gets pattern index and start offset, if handler return true, then match end.
+func (ac *AhoCorasick) LeftmostLongest(text string, handler func(pattern, offset int) bool) {
+	if handler == nil {
+		return
+	}
+	state := int32(0)
+	pending, start, end := -1, 0, 0
+	for i := 0; ; i++ {
+		if i == len(text) && pending < 0 {
+			return
+		}
+		if i < len(text) {
+			state = ac.step(state, ac.byteOf(text[i]))
+			out := state
+			if ac.nodes[out].pattern < 0 {
+				out = ac.nodes[out].dict
+			}
+			for ; out > 0; out = ac.nodes[out].dict {
+				pattern := int(ac.nodes[out].pattern)
+				offset := i + 1 - ac.size[pattern]
+				// 越往后结束的越长, 起点相同时后来者胜
+				if pending < 0 || offset <= start {
+					pending, start, end = pattern, offset, i+1
+				}
+			}
+			// 以后的匹配起点都不早于 i+2-longest, 不会比 pending 更靠左或更长
+			if pending < 0 || i+2-ac.longest <= start {
+				continue
+			}
+		}
+		if handler(pending, start) {
+			return
+		}
+		// pending 之后的匹配可能已被略过, 从它的结尾重新扫描
+		i = end - 1
+		state = 0
+		pending = -1
+	}
+}
+
+// FindLeftmostLongest : the matches of LeftmostLongest
+func (ac *AhoCorasick) FindLeftmostLongest(text string) []ACMatch {
+	var ret []ACMatch
+	ac.LeftmostLongest(text, func(pattern, offset int) bool {
+		ret = append(ret, ACMatch{pattern, offset, offset + ac.size[pattern]})
+		return false
+	})
+	return ret
+}
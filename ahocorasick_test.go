@@ -0,0 +1,116 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// bruteLeftmostLongest : try every start, take the longest pattern there, the first index of equal ones
+func bruteLeftmostLongest(patterns []string, text string, fold bool) []ACMatch {
+	if fold {
+		text = strings.ToLower(text)
+	}
+	var ret []ACMatch
+	for start := 0; start < len(text); {
+		best := -1
+		for id, pattern := range patterns {
+			if fold {
+				pattern = strings.ToLower(pattern)
+			}
+			if len(pattern) == 0 || !strings.HasPrefix(text[start:], pattern) {
+				continue
+			}
+			if best < 0 || len(pattern) > len(patterns[best]) {
+				best = id
+			}
+		}
+		if best < 0 {
+			start++
+			continue
+		}
+		ret = append(ret, ACMatch{best, start, start + len(patterns[best])})
+		start += len(patterns[best])
+	}
+	return ret
+}
+
+func checkLeftmostLongest(t *testing.T, patterns []string, text string, fold bool) {
+	t.Helper()
+	got := NewAhoCorasick(patterns, fold).FindLeftmostLongest(text)
+	want := bruteLeftmostLongest(patterns, text, fold)
+	if len(got) != len(want) {
+		t.Fatalf("%q in %q fold %v: %v, want %v", patterns, text, fold, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%q in %q fold %v: %v, want %v", patterns, text, fold, got, want)
+		}
+	}
+}
+
+func TestAhoCorasickLeftmostLongest(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		text     string
+	}{
+		{[]string{"he", "she", "his", "hers"}, "ushers"},
+		{[]string{"a", "ab", "abc", "abcd"}, "abcabcdab"},
+		{[]string{"abcd", "bc", "c"}, "abcxbcd"},
+		{[]string{"b", "abcde", "cd"}, "abcdxbcd"},
+		{[]string{"ab", "ab", "AB"}, "xabABab"},
+		{[]string{"", "aa"}, "aaaaa"},
+		{[]string{"aaa", "aa"}, "aaaaaaa"},
+		{[]string{"Abc", "bCd"}, "aBCD abcd"},
+		{nil, "abc"},
+		{[]string{"abc"}, ""},
+	}
+	for _, test := range tests {
+		checkLeftmostLongest(t, test.patterns, test.text, false)
+		checkLeftmostLongest(t, test.patterns, test.text, true)
+	}
+	for round := 0; round < 20000; round++ {
+		random := func(n int) string {
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = "abAB"[rand.Intn(4)]
+			}
+			return string(b)
+		}
+		patterns := make([]string, 1+rand.Intn(6))
+		for i := range patterns {
+			switch {
+			case i > 0 && rand.Intn(4) == 0:
+				// 重复或嵌套的模式
+				prev := patterns[rand.Intn(i)]
+				patterns[i] = prev[:rand.Intn(len(prev)+1)]
+			default:
+				patterns[i] = random(rand.Intn(6))
+			}
+		}
+		text := random(rand.Intn(40))
+		checkLeftmostLongest(t, patterns, text, round%2 == 0)
+	}
+}
+
+func TestAhoCorasickStop(t *testing.T) {
+	ac := NewAhoCorasick([]string{"ab"}, false)
+	calls := 0
+	ac.LeftmostLongest("ababab", func(pattern, offset int) bool {
+		calls++
+		return true
+	})
+	if calls != 1 {
+		t.Fatalf("handler called %d times after stop", calls)
+	}
+	calls = 0
+	ac.FindAll("ababab", func(pattern, offset int) bool {
+		calls++
+		return calls == 2
+	})
+	if calls != 2 {
+		t.Fatalf("FindAll handler called %d times, stopped at 2", calls)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrDequeClosed : push after Close, or pop after Close and drained
+var ErrDequeClosed = errors.New("goinline: deque closed")
+
+// BlockingDeque : List guarded for goroutines, pop waits for items, push waits for room
+type BlockingDeque struct {
+	mu       sync.Mutex
+	list     List
+	capacity int
+	closed   bool
+	waiters  int
+	changed  chan struct{} // 状态变化时关闭, 唤醒所有等待者, 下次等待时再建
+}
+
+// NewBlockingDeque : capacity <= 0 means no limit, zero BlockingDeque has no limit too
+func NewBlockingDeque(capacity int) *BlockingDeque {
+	return &BlockingDeque{capacity: capacity}
+}
+
+// Size : items count
+func (d *BlockingDeque) Size() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.list.Size()
+}
+
+// Close : wake all waiters, push fails at once, pop drains the rest then fails
+func (d *BlockingDeque) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		d.broadcast()
+	}
+}
+
+// PushFront : wait for room until ctx done
+func (d *BlockingDeque) PushFront(ctx context.Context, value interface{}) error {
+	return d.push(ctx, value, true)
+}
+
+// PushBack : wait for room until ctx done
+func (d *BlockingDeque) PushBack(ctx context.Context, value interface{}) error {
+	return d.push(ctx, value, false)
+}
+
+// PopFront : wait for an item until ctx done
+func (d *BlockingDeque) PopFront(ctx context.Context) (interface{}, error) {
+	return d.pop(ctx, true)
+}
+
+// PopBack : wait for an item until ctx done
+func (d *BlockingDeque) PopBack(ctx context.Context) (interface{}, error) {
+	return d.pop(ctx, false)
+}
+
+//go:nosplit
+func (d *BlockingDeque) broadcast() {
+	if d.changed != nil {
+		close(d.changed)
+		d.changed = nil
+	}
+}
+
+// wait : called with mu held, return with mu held
+func (d *BlockingDeque) wait(ctx context.Context) error {
+	if d.changed == nil {
+		d.changed = make(chan struct{})
+	}
+	changed := d.changed
+	d.waiters++
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.waiters--
+	}()
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *BlockingDeque) push(ctx context.Context, value interface{}, front bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for {
+		if d.closed {
+			return ErrDequeClosed
+		}
+		if d.capacity <= 0 || d.list.Size() < d.capacity {
+			break
+		}
+		if err := d.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if front {
+		d.list.PushFront(value)
+	} else {
+		d.list.PushBack(value)
+	}
+	if d.waiters > 0 {
+		d.broadcast()
+	}
+	return nil
+}
+
+func (d *BlockingDeque) pop(ctx context.Context, front bool) (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for d.list.Size() == 0 {
+		if d.closed {
+			return nil, ErrDequeClosed
+		}
+		if err := d.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var value interface{}
+	if front {
+		value, _ = d.list.PopFront()
+	} else {
+		value, _ = d.list.PopBack()
+	}
+	if d.waiters > 0 {
+		d.broadcast()
+	}
+	return value, nil
+}
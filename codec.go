@@ -0,0 +1,103 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Codec : convert key or value to bytes and back
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// CodecFuncs : Codec by two functions
+type CodecFuncs struct {
+	EncodeFunc func(v interface{}) ([]byte, error)
+	DecodeFunc func(data []byte) (interface{}, error)
+}
+
+// Encode
+func (c CodecFuncs) Encode(v interface{}) ([]byte, error) {
+	return c.EncodeFunc(v)
+}
+
+// Decode
+func (c CodecFuncs) Decode(data []byte) (interface{}, error) {
+	return c.DecodeFunc(data)
+}
+
+var (
+	// StringCodec : string <=> bytes
+	StringCodec Codec = stringCodec{}
+	// BytesCodec : []byte <=> bytes
+	BytesCodec Codec = bytesCodec{}
+	// IntCodec : int <=> varint
+	IntCodec Codec = intCodec{}
+)
+
+var errCorrupt = errors.New("goinline: corrupt data")
+
+type stringCodec struct{}
+
+func (stringCodec) Encode(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("goinline: StringCodec can not encode %T", v)
+	}
+	return []byte(s), nil
+}
+
+func (stringCodec) Decode(data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("goinline: BytesCodec can not encode %T", v)
+	}
+	return b, nil
+}
+
+func (bytesCodec) Decode(data []byte) (interface{}, error) {
+	return append([]byte(nil), data...), nil
+}
+
+type intCodec struct{}
+
+func (intCodec) Encode(v interface{}) ([]byte, error) {
+	i, ok := v.(int)
+	if !ok {
+		return nil, fmt.Errorf("goinline: IntCodec can not encode %T", v)
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutVarint(buf, int64(i))], nil
+}
+
+func (intCodec) Decode(data []byte) (interface{}, error) {
+	i, n := binary.Varint(data)
+	if n <= 0 || n != len(data) {
+		return nil, errCorrupt
+	}
+	return int(i), nil
+}
+
+//go:nosplit
+func appendUvarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+//go:nosplit
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// KeyTypeError : OrderedCompaire got keys it can not order
+type KeyTypeError struct {
+	A, B interface{}
+}
+
+func (e *KeyTypeError) Error() string {
+	if reflect.TypeOf(e.A) == reflect.TypeOf(e.B) {
+		return fmt.Sprintf("goinline: key type %T is not ordered", e.A)
+	}
+	return fmt.Sprintf("goinline: can not compare key %T with key %T", e.A, e.B)
+}
+
+// recoverKeyType : deferred, turn a *KeyTypeError panic into *err, other panics go on
+func recoverKeyType(err *error) {
+	if r := recover(); r != nil {
+		e, ok := r.(*KeyTypeError)
+		if !ok {
+			panic(r)
+		}
+		*err = e
+	}
+}
+
+//go:nosplit
+func compareOrdered(less, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+//go:nosplit
+func compareFloat(a, b float64) int {
+	// NaN 小于一切, 且与 NaN 相等
+	if a != a {
+		if b != b {
+			return 0
+		}
+		return -1
+	}
+	if b != b {
+		return 1
+	}
+	return compareOrdered(a < b, a > b)
+}
+
+// OrderedCompaire : default compaire of Map and RBtree,
+// support ints, uints, floats, string, []byte and types based on them.
+// Both keys must have the same dynamic type, else it panics with *KeyTypeError
+func OrderedCompaire(a, b interface{}) int {
+	switch x := a.(type) {
+	case int:
+		if y, ok := b.(int); ok {
+			return compareOrdered(x < y, x > y)
+		}
+	case int64:
+		if y, ok := b.(int64); ok {
+			return compareOrdered(x < y, x > y)
+		}
+	case uint64:
+		if y, ok := b.(uint64); ok {
+			return compareOrdered(x < y, x > y)
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return StrCmp(x, y)
+		}
+	case []byte:
+		if y, ok := b.([]byte); ok {
+			return bytes.Compare(x, y)
+		}
+	case float64:
+		if y, ok := b.(float64); ok {
+			return compareFloat(x, y)
+		}
+	}
+	return compareReflect(a, b)
+}
+
+func compareReflect(a, b interface{}) int {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() || va.Type() != vb.Type() {
+		panic(&KeyTypeError{a, b})
+	}
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, y := va.Int(), vb.Int()
+		return compareOrdered(x < y, x > y)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, y := va.Uint(), vb.Uint()
+		return compareOrdered(x < y, x > y)
+	case reflect.Float32, reflect.Float64:
+		return compareFloat(va.Float(), vb.Float())
+	case reflect.String:
+		return StrCmp(va.String(), vb.String())
+	case reflect.Slice:
+		if va.Type().Elem().Kind() == reflect.Uint8 {
+			return bytes.Compare(va.Bytes(), vb.Bytes())
+		}
+	}
+	panic(&KeyTypeError{a, b})
+}
@@ -0,0 +1,460 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// record layout, same for log and snapshot:
+//         crc32(4) | payload size(4) | op(1) | key size(uvarint) | key | value
+const (
+	durableOpSet    byte = 1
+	durableOpRemove byte = 2
+	durableOpEnd    byte = 3
+
+	durableHeadSize  = 8
+	durableMaxRecord = 1 << 30
+
+	durableLogName      = "wal"
+	durableSnapshotName = "snapshot"
+	durableSnapshotTemp = "snapshot.tmp"
+	durableMagic        = "GIDMSNP1"
+)
+
+var durableTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrDurableClosed : DurableMap used after Close
+var ErrDurableClosed = errors.New("goinline: durable map closed")
+
+// ErrDurableCorrupt : the snapshot is damaged, or a bad log record is followed by valid ones,
+// so it is not a torn tail. The files are left untouched
+var ErrDurableCorrupt = errors.New("goinline: durable map corrupt")
+
+// DurableOptions : DurableMap options
+type DurableOptions struct {
+	// SnapshotEvery : write snapshot after this many log records, 0 means 4096, < 0 never
+	SnapshotEvery int
+	// Sync : fsync log after every record
+	Sync bool
+}
+
+// DurableMap : Map with write-ahead log and snapshot in a directory
+type DurableMap struct {
+	data    Map
+	dir     string
+	log     *os.File
+	key     Codec
+	value   Codec
+	opts    DurableOptions
+	records int
+	size    int64 // 日志中完整记录的长度
+	failed  error
+	snaperr error
+	buf     []byte
+}
+
+// OpenDurableMap : open or create the store in dir, recover state from snapshot and log
+func OpenDurableMap(dir string, compaire func(a, b interface{}) int, key, value Codec, opts *DurableOptions) (*DurableMap, error) {
+	d := &DurableMap{dir: dir, key: key, value: value}
+	if opts != nil {
+		d.opts = *opts
+	}
+	if d.opts.SnapshotEvery == 0 {
+		d.opts.SnapshotEvery = 4096
+	}
+	d.data.Init(compaire)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := d.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := d.replayLog(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Map : the in-memory map, must not be modified directly
+func (d *DurableMap) Map() *Map {
+	return &d.data
+}
+
+// Size : items count
+func (d *DurableMap) Size() uint64 {
+	return d.data.Size()
+}
+
+// Find
+func (d *DurableMap) Find(key interface{}) MapIterator {
+	return d.data.Find(key)
+}
+
+// Set : append to log, then update map, a key the compaire can not order is refused before it is logged
+func (d *DurableMap) Set(key, value interface{}) error {
+	if err := d.checkKey(key); err != nil {
+		return err
+	}
+	if err := d.append(durableOpSet, key, value); err != nil {
+		return err
+	}
+	d.data.Set(key, value)
+	d.afterAppend()
+	return nil
+}
+
+// Remove : append to log, then remove from map
+func (d *DurableMap) Remove(key interface{}) error {
+	if err := d.checkKey(key); err != nil {
+		return err
+	}
+	if err := d.append(durableOpRemove, key, nil); err != nil {
+		return err
+	}
+	d.data.Remove(key)
+	d.afterAppend()
+	return nil
+}
+
+// Sync : fsync the log
+func (d *DurableMap) Sync() error {
+	if d.log == nil {
+		return ErrDurableClosed
+	}
+	return d.log.Sync()
+}
+
+// SnapshotErr : error of the last automatic snapshot, nil once one succeeds.
+// Set and Remove do not fail for it, the snapshot is retried on the next record
+func (d *DurableMap) SnapshotErr() error {
+	return d.snaperr
+}
+
+// Snapshot : write sorted snapshot of the map, then reset the log
+func (d *DurableMap) Snapshot() error {
+	if d.log == nil {
+		return ErrDurableClosed
+	}
+	if d.failed != nil {
+		return d.failed
+	}
+	temp := filepath.Join(d.dir, durableSnapshotTemp)
+	f, err := os.OpenFile(temp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = d.writeSnapshot(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(temp, filepath.Join(d.dir, durableSnapshotName))
+	}
+	if err != nil {
+		os.Remove(temp)
+		return err
+	}
+	syncDir(d.dir)
+	// 旧日志中的记录已全部落入快照, 重放也是幂等的
+	if err = d.log.Truncate(0); err != nil {
+		return err
+	}
+	d.records = 0
+	d.size = 0
+	return d.log.Sync()
+}
+
+// Close : sync and close the log
+func (d *DurableMap) Close() error {
+	if d.log == nil {
+		return ErrDurableClosed
+	}
+	err := d.log.Sync()
+	if cerr := d.log.Close(); err == nil {
+		err = cerr
+	}
+	d.log = nil
+	return err
+}
+
+// checkKey : compare key along its insert path, so Set or Remove will not panic after the record is logged
+func (d *DurableMap) checkKey(key interface{}) (err error) {
+	defer recoverKeyType(&err)
+	d.data.Find(key)
+	return nil
+}
+
+func (d *DurableMap) afterAppend() {
+	d.records++
+	if d.opts.SnapshotEvery > 0 && d.records >= d.opts.SnapshotEvery {
+		// 记录已落入日志, 快照失败不影响本次写入, records 不归零下次会重试
+		d.snaperr = d.Snapshot()
+	}
+}
+
+func (d *DurableMap) append(op byte, key, value interface{}) error {
+	if d.log == nil {
+		return ErrDurableClosed
+	}
+	if d.failed != nil {
+		return d.failed
+	}
+	record, err := d.encode(d.buf[:0], op, key, value)
+	if err != nil {
+		return err
+	}
+	d.buf = record
+	if _, err = d.log.Write(record); err != nil {
+		// 写了一半的记录会让之后追加的记录在重放时被当作损坏, 回滚到上一条完整记录
+		if terr := d.log.Truncate(d.size); terr != nil {
+			d.failed = terr
+		}
+		return err
+	}
+	d.size += int64(len(record))
+	if d.opts.Sync {
+		if err = d.log.Sync(); err != nil {
+			// fsync 失败后页缓存状态未知, 不能再保证日志与内存一致
+			d.failed = err
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DurableMap) encode(buf []byte, op byte, key, value interface{}) ([]byte, error) {
+	buf = append(buf, make([]byte, durableHeadSize)...)
+	buf = append(buf, op)
+	if op != durableOpEnd {
+		k, err := d.key.Encode(key)
+		if err != nil {
+			return nil, err
+		}
+		var size [binary.MaxVarintLen64]byte
+		buf = append(buf, size[:binary.PutUvarint(size[:], uint64(len(k)))]...)
+		buf = append(buf, k...)
+	}
+	if op == durableOpSet {
+		v, err := d.value.Encode(value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, v...)
+	}
+	payload := buf[durableHeadSize:]
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.Checksum(payload, durableTable))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(payload)))
+	return buf, nil
+}
+
+// readRecord : return payload of next record, io.EOF on clean end, io.ErrUnexpectedEOF on torn record,
+// errCorrupt on bad record, other errors are from the reader
+func readRecord(r *bufio.Reader, buf []byte) ([]byte, error) {
+	var head [durableHeadSize]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(head[4:8])
+	if size == 0 || size > durableMaxRecord {
+		return nil, errCorrupt
+	}
+	if cap(buf) < int(size) {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if crc32.Checksum(buf, durableTable) != binary.LittleEndian.Uint32(head[0:4]) {
+		return nil, errCorrupt
+	}
+	return buf, nil
+}
+
+func (d *DurableMap) apply(payload []byte) (op byte, err error) {
+	// 旧版本可能已写入无法比较的键, 恢复时报错而不是崩溃
+	defer recoverKeyType(&err)
+	op = payload[0]
+	if op == durableOpEnd {
+		return op, nil
+	}
+	size, n := binary.Uvarint(payload[1:])
+	if n <= 0 || uint64(len(payload)-1-n) < size {
+		return op, errCorrupt
+	}
+	raw := payload[1+n:]
+	key, err := d.key.Decode(raw[:size])
+	if err != nil {
+		return op, err
+	}
+	switch op {
+	case durableOpSet:
+		value, err := d.value.Decode(raw[size:])
+		if err != nil {
+			return op, err
+		}
+		d.data.Set(key, value)
+	case durableOpRemove:
+		d.data.Remove(key)
+	default:
+		return op, errCorrupt
+	}
+	return op, nil
+}
+
+func (d *DurableMap) writeSnapshot(w io.Writer) error {
+	if _, err := io.WriteString(w, durableMagic); err != nil {
+		return err
+	}
+	buf := d.buf[:0]
+	for it := d.data.Begin(); !it.IsEnd(); it = it.Next() {
+		p := it.Value()
+		record, err := d.encode(buf[:0], durableOpSet, p.Key(), p.Value)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(record); err != nil {
+			return err
+		}
+		buf = record
+	}
+	record, _ := d.encode(buf[:0], durableOpEnd, nil, nil)
+	_, err := w.Write(record)
+	return err
+}
+
+func (d *DurableMap) loadSnapshot() error {
+	f, err := os.Open(filepath.Join(d.dir, durableSnapshotName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(durableMagic))
+	if _, err = io.ReadFull(r, magic); err != nil || string(magic) != durableMagic {
+		return durableSnapshotErr(err)
+	}
+	var buf []byte
+	for {
+		payload, err := readRecord(r, buf)
+		if err != nil {
+			return durableSnapshotErr(err)
+		}
+		buf = payload
+		op, err := d.apply(payload)
+		if err != nil {
+			return durableSnapshotErr(err)
+		}
+		if op == durableOpEnd {
+			return nil
+		}
+	}
+}
+
+// durableSnapshotErr : snapshot is replaced atomically by rename, so a short or bad one is corrupt,
+// I/O and codec errors are returned as they are
+func durableSnapshotErr(err error) error {
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF || err == errCorrupt {
+		return ErrDurableCorrupt
+	}
+	return err
+}
+
+func (d *DurableMap) replayLog() error {
+	f, err := os.OpenFile(filepath.Join(d.dir, durableLogName), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReader(f)
+	var good int64
+	var buf []byte
+	for {
+		payload, err := readRecord(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF || err == errCorrupt {
+			// 只有最后一条记录写了一半才可截断, 其后还有完整记录说明是中间损坏
+			if err = durableTornTail(f, good); err == nil {
+				err = f.Truncate(good)
+			}
+			if err != nil {
+				f.Close()
+				return err
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+		buf = payload
+		if _, err = d.apply(payload); err != nil {
+			f.Close()
+			return err
+		}
+		good += int64(durableHeadSize + len(payload))
+		d.records++
+	}
+	d.log = f
+	d.size = good
+	return nil
+}
+
+// durableTornTail : nil if no valid record starts anywhere after the bad one at offset good
+func durableTornTail(f *os.File, good int64) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	tail := make([]byte, info.Size()-good)
+	if _, err = f.ReadAt(tail, good); err != nil && err != io.EOF {
+		return err
+	}
+	for off := 1; off+durableHeadSize < len(tail); off++ {
+		if durableValidAt(tail[off:]) {
+			return ErrDurableCorrupt
+		}
+	}
+	return nil
+}
+
+//go:nosplit
+func durableValidAt(b []byte) bool {
+	size := binary.LittleEndian.Uint32(b[4:8])
+	if size == 0 || uint64(size) > uint64(len(b)-durableHeadSize) {
+		return false
+	}
+	payload := b[durableHeadSize : durableHeadSize+int(size)]
+	if payload[0] != durableOpSet && payload[0] != durableOpRemove {
+		return false
+	}
+	return crc32.Checksum(payload, durableTable) == binary.LittleEndian.Uint32(b[0:4])
+}
+
+func syncDir(dir string) {
+	if f, err := os.Open(dir); err == nil {
+		f.Sync()
+		f.Close()
+	}
+}
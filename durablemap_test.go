@@ -0,0 +1,163 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDurable(t *testing.T, dir string) *DurableMap {
+	t.Helper()
+	d, err := OpenDurableMap(dir, nil, StringCodec, IntCodec, &DurableOptions{SnapshotEvery: -1})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return d
+}
+
+func checkDurable(t *testing.T, d *DurableMap, want map[string]int) {
+	t.Helper()
+	if d.Size() != uint64(len(want)) {
+		t.Fatalf("size %d, want %d", d.Size(), len(want))
+	}
+	for k, v := range want {
+		it := d.Find(k)
+		if it.IsEnd() {
+			t.Fatalf("key %q lost", k)
+		}
+		if got := it.Value().Value; got != v {
+			t.Fatalf("key %q is %v, want %d", k, got, v)
+		}
+	}
+}
+
+func TestDurableMapTornTail(t *testing.T) {
+	dir := t.TempDir()
+	d := openTestDurable(t, dir)
+	d.Set("a", 1)
+	d.Set("b", 2)
+	d.Set("c", 3)
+	d.Close()
+
+	wal := filepath.Join(dir, durableLogName)
+	raw, _ := os.ReadFile(wal)
+	// 最后一条记录只写了一半
+	os.WriteFile(wal, raw[:len(raw)-3], 0644)
+
+	d = openTestDurable(t, dir)
+	checkDurable(t, d, map[string]int{"a": 1, "b": 2})
+	// 截断后追加的记录可以正常重放
+	d.Set("d", 4)
+	d.Close()
+	d = openTestDurable(t, dir)
+	checkDurable(t, d, map[string]int{"a": 1, "b": 2, "d": 4})
+	d.Close()
+}
+
+func TestDurableMapMidLogCorruption(t *testing.T) {
+	dir := t.TempDir()
+	d := openTestDurable(t, dir)
+	d.Set("a", 1)
+	d.Set("b", 2)
+	d.Set("c", 3)
+	d.Close()
+
+	wal := filepath.Join(dir, durableLogName)
+	raw, _ := os.ReadFile(wal)
+	raw[durableHeadSize+2]++
+	os.WriteFile(wal, raw, 0644)
+
+	if _, err := OpenDurableMap(dir, nil, StringCodec, IntCodec, nil); err != ErrDurableCorrupt {
+		t.Fatalf("open corrupt log: %v, want ErrDurableCorrupt", err)
+	}
+	after, _ := os.ReadFile(wal)
+	if len(after) != len(raw) {
+		t.Fatalf("corrupt log truncated to %d bytes", len(after))
+	}
+}
+
+func TestDurableMapCrashAfterSnapshotRename(t *testing.T) {
+	dir := t.TempDir()
+	d := openTestDurable(t, dir)
+	d.Set("a", 1)
+	d.Set("b", 2)
+	d.Remove("a")
+	d.Set("c", 3)
+	d.Sync()
+
+	wal := filepath.Join(dir, durableLogName)
+	raw, _ := os.ReadFile(wal)
+	if err := d.Snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	d.Close()
+	// 快照已 rename 但日志未截断
+	os.WriteFile(wal, raw, 0644)
+
+	d = openTestDurable(t, dir)
+	checkDurable(t, d, map[string]int{"b": 2, "c": 3})
+	d.Close()
+}
+
+var jsonTestCodec = CodecFuncs{
+	EncodeFunc: func(v interface{}) ([]byte, error) { return json.Marshal(v) },
+	DecodeFunc: func(data []byte) (interface{}, error) {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return v, err
+	},
+}
+
+func TestDurableMapKeyType(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDurableMap(dir, nil, jsonTestCodec, jsonTestCodec, nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d.Set("a", 1)
+	if err = d.Set(2.0, 1); err == nil {
+		t.Fatalf("set float key into string keys: no error")
+	}
+	if _, ok := err.(*KeyTypeError); !ok {
+		t.Fatalf("set float key into string keys: %v, want *KeyTypeError", err)
+	}
+	if err = d.Remove(2.0); err == nil {
+		t.Fatalf("remove float key from string keys: no error")
+	}
+	// 绕过检查写入日志, 重放时也只能报错
+	d.append(durableOpSet, 3.0, 1)
+	d.Close()
+
+	if _, err = OpenDurableMap(dir, nil, jsonTestCodec, jsonTestCodec, nil); err == nil {
+		t.Fatalf("replay float key into string keys: no error")
+	}
+}
+
+func TestDurableMapCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	d := openTestDurable(t, dir)
+	d.Set("a", 1)
+	d.Set("b", 2)
+	if err := d.Snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	d.Close()
+
+	snapshot := filepath.Join(dir, durableSnapshotName)
+	raw, _ := os.ReadFile(snapshot)
+	for _, bad := range [][]byte{raw[:len(raw)-1], raw[:4], append([]byte("GIDMSNP0"), raw[len(durableMagic):]...)} {
+		os.WriteFile(snapshot, bad, 0644)
+		if _, err := OpenDurableMap(dir, nil, StringCodec, IntCodec, nil); err != ErrDurableCorrupt {
+			t.Fatalf("open %d bytes snapshot: %v, want ErrDurableCorrupt", len(bad), err)
+		}
+	}
+	flipped := append([]byte(nil), raw...)
+	flipped[len(durableMagic)+durableHeadSize+2]++
+	os.WriteFile(snapshot, flipped, 0644)
+	if _, err := OpenDurableMap(dir, nil, StringCodec, IntCodec, nil); err != ErrDurableCorrupt {
+		t.Fatalf("open snapshot with bad crc: %v, want ErrDurableCorrupt", err)
+	}
+}
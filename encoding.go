@@ -0,0 +1,298 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const encodingMaxItem = 1 << 30
+
+var errJSONPaire = errors.New("goinline: map json array item must be [key, value]")
+
+// MarshalJSON : list as json array
+func (l List) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteByte('[')
+	for n := l.first; n != nil; n = n.nxt {
+		if n != l.first {
+			buf.WriteByte(',')
+		}
+		raw, err := json.Marshal(n.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(raw)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON : replace items by json array
+func (l *List) UnmarshalJSON(data []byte) error {
+	var values []interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	l.Clear()
+	for _, v := range values {
+		l.PushBack(v)
+	}
+	return nil
+}
+
+// MarshalJSON : json object in key order if all keys are string, else array of [key, value] in key order.
+// Number keys come back from UnmarshalJSON as float64, so an int keyed Map does not round trip
+// and Find(0) on it panics with *KeyTypeError, decode such keys with UnmarshalJSONWith
+func (m Map) MarshalJSON() ([]byte, error) {
+	object := true
+	for it := m.Begin(); !it.IsEnd() && object; it = it.Next() {
+		_, object = it.node.Value.first.(string)
+	}
+	buf := bytes.NewBuffer(nil)
+	if object {
+		buf.WriteByte('{')
+	} else {
+		buf.WriteByte('[')
+	}
+	for it := m.Begin(); !it.IsEnd(); it = it.Next() {
+		if it.node.Pre() != nil {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(it.node.Value.first)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(it.node.Value.Value)
+		if err != nil {
+			return nil, err
+		}
+		if object {
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		} else {
+			buf.WriteByte('[')
+			buf.Write(key)
+			buf.WriteByte(',')
+			buf.Write(value)
+			buf.WriteByte(']')
+		}
+	}
+	if object {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON : replace items by json object or array of [key, value], keys are ordered by the map compaire.
+// Keys and values are decoded like json.Unmarshal into interface{}, so numbers are float64, use UnmarshalJSONWith for other types
+func (m *Map) UnmarshalJSON(data []byte) error {
+	return m.UnmarshalJSONWith(data, nil, nil)
+}
+
+// UnmarshalJSONWith : same as UnmarshalJSON, key and value decode each raw json item, nil means json.Unmarshal into interface{}.
+// Keys of an object come as json strings. If the map compaire panics with *KeyTypeError, the map is unchanged and it is returned as error
+func (m *Map) UnmarshalJSONWith(data []byte, key, value func(raw json.RawMessage) (interface{}, error)) error {
+	if key == nil {
+		key = decodeJSONRaw
+	}
+	if value == nil {
+		value = decodeJSONRaw
+	}
+	var paires []RBTpaire
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) > 0 && data[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			rawKey, err := json.Marshal(tok)
+			if err != nil {
+				return err
+			}
+			var rawValue json.RawMessage
+			if err = dec.Decode(&rawValue); err != nil {
+				return err
+			}
+			var p RBTpaire
+			if p.first, err = key(rawKey); err != nil {
+				return err
+			}
+			if p.Value, err = value(rawValue); err != nil {
+				return err
+			}
+			paires = append(paires, p)
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	} else {
+		var items [][]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if len(item) != 2 {
+				return errJSONPaire
+			}
+			var p RBTpaire
+			var err error
+			if p.first, err = key(item[0]); err != nil {
+				return err
+			}
+			if p.Value, err = value(item[1]); err != nil {
+				return err
+			}
+			paires = append(paires, p)
+		}
+	}
+	return m.replace(paires)
+}
+
+func decodeJSONRaw(raw json.RawMessage) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// replace : set paires into a scratch map first, so a *KeyTypeError leaves m unchanged, other panics go on
+func (m *Map) replace(paires []RBTpaire) (err error) {
+	defer recoverKeyType(&err)
+	var tmp Map
+	tmp.Init(m.tree.compairer())
+	for _, p := range paires {
+		tmp.Set(p.first, p.Value)
+	}
+	m.Clear()
+	for it := tmp.Begin(); !it.IsEnd(); it = it.Next() {
+		m.Set(it.node.Value.first, it.node.Value.Value)
+	}
+	return nil
+}
+
+func writeItem(w io.Writer, raw []byte) error {
+	var size [binary.MaxVarintLen64]byte
+	if _, err := w.Write(size[:binary.PutUvarint(size[:], uint64(len(raw)))]); err != nil {
+		return err
+	}
+	_, err := w.Write(raw)
+	return err
+}
+
+func readItem(r *bufio.Reader, codec Codec) (interface{}, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > encodingMaxItem {
+		return nil, errCorrupt
+	}
+	raw := make([]byte, size)
+	if _, err = io.ReadFull(r, raw); err != nil {
+		return nil, err
+	}
+	return codec.Decode(raw)
+}
+
+func readCount(r *bufio.Reader) (uint64, error) {
+	count, err := binary.ReadUvarint(r)
+	if err == nil && count > encodingMaxItem {
+		err = errCorrupt
+	}
+	return count, err
+}
+
+// EncodeList : count(uvarint) | (size(uvarint) | item) ...
+func EncodeList(w io.Writer, l *List, codec Codec) error {
+	bw := bufio.NewWriter(w)
+	var size [binary.MaxVarintLen64]byte
+	bw.Write(size[:binary.PutUvarint(size[:], uint64(l.count))])
+	for n := l.first; n != nil; n = n.nxt {
+		raw, err := codec.Encode(n.value)
+		if err != nil {
+			return err
+		}
+		if err = writeItem(bw, raw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeList : replace items by EncodeList output, r is buffered so it may be read past the end
+func DecodeList(r io.Reader, l *List, codec Codec) error {
+	br := bufio.NewReader(r)
+	count, err := readCount(br)
+	if err != nil {
+		return err
+	}
+	var tmp List
+	for ; count > 0; count-- {
+		value, err := readItem(br, codec)
+		if err != nil {
+			return err
+		}
+		tmp.PushBack(value)
+	}
+	l.Clear()
+	l.Splice(l.End(), &tmp, tmp.Front(), tmp.End())
+	return nil
+}
+
+// EncodeMap : count(uvarint) | (key size(uvarint) | key | value size(uvarint) | value) ... in key order
+func EncodeMap(w io.Writer, m *Map, key, value Codec) error {
+	bw := bufio.NewWriter(w)
+	var size [binary.MaxVarintLen64]byte
+	bw.Write(size[:binary.PutUvarint(size[:], m.Size())])
+	for it := m.Begin(); !it.IsEnd(); it = it.Next() {
+		k, err := key.Encode(it.node.Value.first)
+		if err != nil {
+			return err
+		}
+		v, err := value.Encode(it.node.Value.Value)
+		if err != nil {
+			return err
+		}
+		if err = writeItem(bw, k); err != nil {
+			return err
+		}
+		if err = writeItem(bw, v); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeMap : replace items by EncodeMap output, r is buffered so it may be read past the end
+func DecodeMap(r io.Reader, m *Map, key, value Codec) error {
+	br := bufio.NewReader(r)
+	count, err := readCount(br)
+	if err != nil {
+		return err
+	}
+	paires := make([]RBTpaire, 0, min(int(count), 1024))
+	for ; count > 0; count-- {
+		var p RBTpaire
+		if p.first, err = readItem(br, key); err != nil {
+			return err
+		}
+		if p.Value, err = readItem(br, value); err != nil {
+			return err
+		}
+		paires = append(paires, p)
+	}
+	return m.replace(paires)
+}
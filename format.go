@@ -0,0 +1,117 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"fmt"
+	"io"
+)
+
+// FormatMaxItems : containers print at most this many items, the rest is counted if the size is known
+var FormatMaxItems = 100
+
+//go:nosplit
+func formatVerb(f fmt.State) string {
+	if f.Flag('+') {
+		return "%+v"
+	}
+	return "%v"
+}
+
+func formatMore(w io.Writer, rest uint64) {
+	if rest > 0 {
+		fmt.Fprintf(w, " ...+%d", rest)
+	}
+}
+
+// formatTree : print from root in order, return how many were printed and the first node not printed
+func formatTree(f fmt.State, root *RBTnode) (uint64, *RBTnode) {
+	verb := formatVerb(f)
+	shown := 0
+	node := root
+	for ; node != nil && shown < FormatMaxItems; node = node.Next() {
+		if shown > 0 {
+			io.WriteString(f, " ")
+		}
+		fmt.Fprintf(f, verb+":"+verb, node.Value.first, node.Value.Value)
+		shown++
+	}
+	return uint64(shown), node
+}
+
+// Format : %v prints map[k1:v1 k2:v2] in key order, %+v adds size and height, it walks the whole tree like RBtree
+func (m Map) Format(f fmt.State, verb rune) {
+	if verb != 'v' && verb != 's' {
+		fmt.Fprintf(f, "%%!%c(goinline.Map)", verb)
+		return
+	}
+	if f.Flag('+') {
+		fmt.Fprintf(f, "map(size=%d height=%d)", m.size, m.tree.Stats().Height)
+	} else {
+		io.WriteString(f, "map")
+	}
+	io.WriteString(f, "[")
+	shown, _ := formatTree(f, m.tree.Begin())
+	formatMore(f, m.size-shown)
+	io.WriteString(f, "]")
+}
+
+// String : same as %v
+func (m Map) String() string {
+	return fmt.Sprintf("%v", m)
+}
+
+// Format : %v prints rbtree[k1:v1 k2:v2] in key order and " ..." if more items are not printed,
+// RBtree keeps no size, so only %+v walks the whole tree to add nodes count and height
+func (rbt RBtree) Format(f fmt.State, verb rune) {
+	if verb != 'v' && verb != 's' {
+		fmt.Fprintf(f, "%%!%c(goinline.RBtree)", verb)
+		return
+	}
+	if !f.Flag('+') {
+		io.WriteString(f, "rbtree[")
+		if _, rest := formatTree(f, rbt.Begin()); rest != nil {
+			io.WriteString(f, " ...")
+		}
+		io.WriteString(f, "]")
+		return
+	}
+	st := rbt.Stats()
+	fmt.Fprintf(f, "rbtree(nodes=%d height=%d)[", st.Nodes, st.Height)
+	shown, _ := formatTree(f, rbt.Begin())
+	formatMore(f, st.Nodes-shown)
+	io.WriteString(f, "]")
+}
+
+// String : same as %v
+func (rbt RBtree) String() string {
+	return fmt.Sprintf("%v", rbt)
+}
+
+// Format : %v prints like a slice [a b c], %+v adds size
+func (l List) Format(f fmt.State, verb rune) {
+	if verb != 'v' && verb != 's' {
+		fmt.Fprintf(f, "%%!%c(goinline.List)", verb)
+		return
+	}
+	if f.Flag('+') {
+		fmt.Fprintf(f, "list(size=%d)", l.count)
+	}
+	io.WriteString(f, "[")
+	item := formatVerb(f)
+	shown := 0
+	for n := l.first; n != nil && shown < FormatMaxItems; n = n.nxt {
+		if shown > 0 {
+			io.WriteString(f, " ")
+		}
+		fmt.Fprintf(f, item, n.value)
+		shown++
+	}
+	formatMore(f, uint64(l.count-shown))
+	io.WriteString(f, "]")
+}
+
+// String : same as %v
+func (l List) String() string {
+	return fmt.Sprintf("%v", l)
+}
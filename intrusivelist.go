@@ -0,0 +1,216 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+// ListHook : embed in T to link T into an IntrusiveList, one hook for each list T may be in
+type ListHook[T any] struct {
+	pre  *T
+	nxt  *T
+	list *IntrusiveList[T]
+}
+
+// Linked : hook is in a list
+//go:nosplit
+func (h *ListHook[T]) Linked() bool {
+	return h.list != nil
+}
+
+// IntrusiveList : doubly linked list of T through a ListHook inside T, push and remove never allocate
+//
+//	type Conn struct {
+//		byTime ListHook[Conn]
+//		byIdle ListHook[Conn]
+//	}
+//	timers := NewIntrusiveList(func(c *Conn) *ListHook[Conn] { return &c.byTime })
+type IntrusiveList[T any] struct {
+	hook  func(*T) *ListHook[T]
+	first *T
+	last  *T
+	count int
+}
+
+// NewIntrusiveList : hook returns the hook of this list inside an element
+func NewIntrusiveList[T any](hook func(*T) *ListHook[T]) *IntrusiveList[T] {
+	return (&IntrusiveList[T]{}).Init(hook)
+}
+
+// Init : the list constructor
+func (l *IntrusiveList[T]) Init(hook func(*T) *ListHook[T]) *IntrusiveList[T] {
+	l.hook = hook
+	l.first = nil
+	l.last = nil
+	l.count = 0
+	return l
+}
+
+// Size
+//go:nosplit
+func (l *IntrusiveList[T]) Size() int {
+	return l.count
+}
+
+// Front : first element, nil if empty
+//go:nosplit
+func (l *IntrusiveList[T]) Front() *T {
+	return l.first
+}
+
+// Back : last element, nil if empty
+//go:nosplit
+func (l *IntrusiveList[T]) Back() *T {
+	return l.last
+}
+
+// Contains : e is in this list
+func (l *IntrusiveList[T]) Contains(e *T) bool {
+	return e != nil && l.hook(e).list == l
+}
+
+// Next : element after e, nil at end or if e is not in this list
+func (l *IntrusiveList[T]) Next(e *T) *T {
+	if !l.Contains(e) {
+		return nil
+	}
+	return l.hook(e).nxt
+}
+
+// Pre : element before e, nil at front or if e is not in this list
+func (l *IntrusiveList[T]) Pre(e *T) *T {
+	if !l.Contains(e) {
+		return nil
+	}
+	return l.hook(e).pre
+}
+
+// link : put e before at, at == nil means back
+func (l *IntrusiveList[T]) link(e, at *T) {
+	h := l.hook(e)
+	if at == nil {
+		h.pre = l.last
+		l.last = e
+	} else {
+		ah := l.hook(at)
+		h.pre = ah.pre
+		ah.pre = e
+	}
+	if h.pre == nil {
+		l.first = e
+	} else {
+		l.hook(h.pre).nxt = e
+	}
+	h.nxt = at
+	h.list = l
+	l.count++
+}
+
+func (l *IntrusiveList[T]) unlink(e *T) {
+	h := l.hook(e)
+	if h.pre == nil {
+		l.first = h.nxt
+	} else {
+		l.hook(h.pre).nxt = h.nxt
+	}
+	if h.nxt == nil {
+		l.last = h.pre
+	} else {
+		l.hook(h.nxt).pre = h.pre
+	}
+	h.pre = nil
+	h.nxt = nil
+	h.list = nil
+	l.count--
+}
+
+// PushFront : false if e is already in a list through this hook
+func (l *IntrusiveList[T]) PushFront(e *T) bool {
+	if e == nil || l.hook(e).list != nil {
+		return false
+	}
+	l.link(e, l.first)
+	return true
+}
+
+// PushBack : false if e is already in a list through this hook
+func (l *IntrusiveList[T]) PushBack(e *T) bool {
+	if e == nil || l.hook(e).list != nil {
+		return false
+	}
+	l.link(e, nil)
+	return true
+}
+
+// InsertBefore : put e front of mark
+func (l *IntrusiveList[T]) InsertBefore(e, mark *T) bool {
+	if e == nil || l.hook(e).list != nil || !l.Contains(mark) {
+		return false
+	}
+	l.link(e, mark)
+	return true
+}
+
+// InsertAfter : put e back of mark
+func (l *IntrusiveList[T]) InsertAfter(e, mark *T) bool {
+	if e == nil || l.hook(e).list != nil || !l.Contains(mark) {
+		return false
+	}
+	l.link(e, l.hook(mark).nxt)
+	return true
+}
+
+// Remove : false if e is not in this list
+func (l *IntrusiveList[T]) Remove(e *T) bool {
+	if !l.Contains(e) {
+		return false
+	}
+	l.unlink(e)
+	return true
+}
+
+// PopFront : remove and return first element, nil if empty
+func (l *IntrusiveList[T]) PopFront() *T {
+	e := l.first
+	if e != nil {
+		l.unlink(e)
+	}
+	return e
+}
+
+// PopBack : remove and return last element, nil if empty
+func (l *IntrusiveList[T]) PopBack() *T {
+	e := l.last
+	if e != nil {
+		l.unlink(e)
+	}
+	return e
+}
+
+// MoveToFront
+func (l *IntrusiveList[T]) MoveToFront(e *T) bool {
+	if !l.Contains(e) {
+		return false
+	}
+	if l.first != e {
+		l.unlink(e)
+		l.link(e, l.first)
+	}
+	return true
+}
+
+// MoveToBack
+func (l *IntrusiveList[T]) MoveToBack(e *T) bool {
+	if !l.Contains(e) {
+		return false
+	}
+	if l.last != e {
+		l.unlink(e)
+		l.link(e, nil)
+	}
+	return true
+}
+
+// Clear : unlink all elements
+func (l *IntrusiveList[T]) Clear() {
+	for l.first != nil {
+		l.unlink(l.first)
+	}
+}
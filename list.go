@@ -2,10 +2,30 @@
 
 package goinline
 
+import "reflect"
+
 type listNode struct {
 	pre   *listNode
 	nxt   *listNode
 	value interface{}
+	owner *listOwner
+	gen   uint32 // 节点每次离开链表加一, 旧迭代器随之失效
+}
+
+// listOwner : nodes point to a shared owner cell, not to the List, so a whole list
+// changes hands by joining two cells. Cells are joined by rank, the root one holds the list
+type listOwner struct {
+	list *List
+	up   *listOwner
+	rank uint8
+}
+
+//go:nosplit
+func (o *listOwner) root() *listOwner {
+	for o.up != nil {
+		o = o.up
+	}
+	return o
 }
 
 //go:nosplit
@@ -19,18 +39,19 @@ func (n *listNode) reset() {
 type ListIterator struct {
 	root *List
 	node *listNode
+	gen  uint32
 }
 
 //Set : set the node value
 func (i ListIterator) Set(value interface{}) bool {
-	if i.node == nil {
+	if i.node == nil || !i.Valid() {
 		return false
 	}
 	i.node.value = value
 	return true
 }
 
-//Valid : check iterator valid
+//Valid : check iterator valid, false if the node was removed or is in another list now
 //go:nosplit
 func (i ListIterator) Valid() bool {
 	if i.root == nil {
@@ -39,50 +60,48 @@ func (i ListIterator) Valid() bool {
 	if i.node == nil {
 		return true
 	}
-	if i.root.count == 0 {
-		return false
-	}
-	if i.node.pre == nil {
-		return i.root.first == i.node
-	}
-	if i.node.nxt == nil {
-		return i.root.last == i.node
-	}
-	return i.node.pre.nxt == i.node && i.node.nxt.pre == i.node
+	return i.node.owner != nil && i.node.owner.root().list == i.root && i.node.gen == i.gen
 }
 
 //Destroy iterator
 //go:nosplit
-func (i ListIterator) Destroy() {
+func (i *ListIterator) Destroy() {
 	i.node = nil
+	i.gen = 0
 }
 
-//Remove node from list
-func (i ListIterator) Remove() (interface{}, bool) {
-	if !i.Valid() || i.root.count == 0 {
-		return nil, false
-	}
-	defer i.Destroy()
-	if i.node.pre == nil {
-		return i.root.PopFront()
+//Remove node from list, return the next iterator, so erase in loop is safe:
+//
+//	for it := l.Front(); it.Valid() && !it.IsEnd(); {
+//		if remove {
+//			it, _, _ = it.Remove()
+//		} else {
+//			it = it.Back()
+//		}
+//	}
+func (i ListIterator) Remove() (ListIterator, interface{}, bool) {
+	if i.node == nil || !i.Valid() {
+		return i.root.iterator(nil), nil, false
 	}
-	if i.node.nxt == nil {
-		return i.root.PopBack()
-	}
-	i.node.pre.nxt = i.node.nxt
-	i.node.nxt.pre = i.node.pre
-	i.root.count--
+	next := i.node.nxt
 	ret := i.node.value
-	i.node.reset()
-	return ret, true
+	i.root.unlink(i.node)
+	i.root.freenode(i.node)
+	return i.root.iterator(next), ret, true
+}
+
+//IsEnd : iterator is End
+//go:nosplit
+func (i ListIterator) IsEnd() bool {
+	return i.node == nil
 }
 
 //InsertFront : insert a node front of current
 func (i ListIterator) InsertFront(value interface{}) (ListIterator, bool) {
 	if !i.Valid() || i.node == nil {
-		return ListIterator{i.root, nil}, false
+		return i.root.iterator(nil), false
 	}
-	n := &listNode{i.node.pre, i.node, value}
+	n := i.root.newnode(i.node.pre, i.node, value)
 	i.node.pre = n
 	if n.pre == nil {
 		i.root.first = n
@@ -90,15 +109,15 @@ func (i ListIterator) InsertFront(value interface{}) (ListIterator, bool) {
 		n.pre.nxt = n
 	}
 	i.root.count++
-	return ListIterator{i.root, n}, true
+	return i.root.iterator(n), true
 }
 
 //InsertBack : insert a node back of current
 func (i ListIterator) InsertBack(value interface{}) (ListIterator, bool) {
 	if !i.Valid() || i.node == nil {
-		return ListIterator{i.root, nil}, false
+		return i.root.iterator(nil), false
 	}
-	n := &listNode{i.node, i.node.nxt, value}
+	n := i.root.newnode(i.node, i.node.nxt, value)
 	i.node.nxt = n
 	if n.nxt == nil {
 		i.root.last = n
@@ -106,7 +125,7 @@ func (i ListIterator) InsertBack(value interface{}) (ListIterator, bool) {
 		n.nxt.pre = n
 	}
 	i.root.count++
-	return ListIterator{i.root, n}, true
+	return i.root.iterator(n), true
 }
 
 //Value
@@ -124,9 +143,9 @@ func (i ListIterator) Value() (interface{}, bool) {
 func (i ListIterator) Back() ListIterator {
 
 	if i.Valid() && i.node != nil {
-		return ListIterator{root: i.root, node: i.node.nxt}
+		return i.root.iterator(i.node.nxt)
 	}
-	return ListIterator{i.root, nil}
+	return i.root.iterator(nil)
 }
 
 //Front
@@ -134,9 +153,9 @@ func (i ListIterator) Back() ListIterator {
 func (i ListIterator) Front() ListIterator {
 
 	if i.Valid() && i.node != nil {
-		return ListIterator{root: i.root, node: i.node.pre}
+		return i.root.iterator(i.node.pre)
 	}
-	return ListIterator{i.root, nil}
+	return i.root.iterator(nil)
 }
 
 //List : general list
@@ -144,6 +163,55 @@ type List struct {
 	first *listNode
 	last  *listNode
 	count int
+	pool  *NodePool
+	owner *listOwner // 根 cell, 首次使用时创建
+}
+
+//SetPool : take nodes from pool and give removed nodes back, nil means allocate every node.
+//Iterators of removed nodes must not be used then, the node may be in use again
+func (l *List) SetPool(pool *NodePool) *List {
+	l.pool = pool
+	return l
+}
+
+//go:nosplit
+func (l *List) cell() *listOwner {
+	if l.owner == nil {
+		l.owner = &listOwner{list: l}
+	}
+	return l.owner
+}
+
+//go:nosplit
+func (l *List) newnode(pre, nxt *listNode, value interface{}) *listNode {
+	if l.pool == nil {
+		return &listNode{pre: pre, nxt: nxt, value: value, owner: l.cell()}
+	}
+	n := l.pool.getListNode()
+	n.pre = pre
+	n.nxt = nxt
+	n.value = value
+	n.owner = l.cell()
+	return n
+}
+
+//go:nosplit
+func (l *List) freenode(n *listNode) {
+	n.owner = nil
+	n.gen++
+	if l.pool == nil {
+		n.reset()
+	} else {
+		l.pool.putListNode(n)
+	}
+}
+
+//go:nosplit
+func (l *List) iterator(n *listNode) ListIterator {
+	if n == nil {
+		return ListIterator{l, nil, 0}
+	}
+	return ListIterator{l, n, n.gen}
 }
 
 //Size
@@ -159,24 +227,24 @@ func (l *List) Clear() {
 	}
 }
 func (l *List) End() ListIterator {
-	return ListIterator{l, nil}
+	return l.iterator(nil)
 }
 
 //Front
 //go:nosplit
 func (l *List) Front() ListIterator {
-	return ListIterator{l, l.first}
+	return l.iterator(l.first)
 }
 
 //Back
 //go:nosplit
 func (l *List) Back() ListIterator {
-	return ListIterator{l, l.last}
+	return l.iterator(l.last)
 }
 
 //PushBack
 func (l *List) PushBack(value interface{}) ListIterator {
-	n := &listNode{l.last, nil, value}
+	n := l.newnode(l.last, nil, value)
 	if l.count == 0 {
 		l.first = n
 	} else {
@@ -184,12 +252,12 @@ func (l *List) PushBack(value interface{}) ListIterator {
 	}
 	l.last = n
 	l.count++
-	return ListIterator{l, n}
+	return l.iterator(n)
 }
 
 //PushFront
 func (l *List) PushFront(value interface{}) ListIterator {
-	n := &listNode{nil, l.first, value}
+	n := l.newnode(nil, l.first, value)
 	if l.count == 0 {
 		l.last = n
 	} else {
@@ -197,7 +265,7 @@ func (l *List) PushFront(value interface{}) ListIterator {
 	}
 	l.first = n
 	l.count++
-	return ListIterator{l, n}
+	return l.iterator(n)
 }
 
 //PopFront
@@ -208,14 +276,13 @@ func (l *List) PopFront() (interface{}, bool) {
 	rnode := l.first
 	ret := rnode.value
 	l.first = rnode.nxt
-	rnode.value = nil
 	l.count--
 	if l.count == 0 {
 		l.last = nil
 	} else {
 		l.first.pre = nil
-		rnode.nxt = nil
 	}
+	l.freenode(rnode)
 	return ret, true
 }
 
@@ -227,13 +294,430 @@ func (l *List) PopBack() (interface{}, bool) {
 	rnode := l.last
 	ret := rnode.value
 	l.last = rnode.pre
-	rnode.value = nil
 	l.count--
 	if l.count == 0 {
 		l.first = nil
 	} else {
 		l.last.nxt = nil
-		rnode.pre = nil
 	}
+	l.freenode(rnode)
 	return ret, true
 }
+
+//go:nosplit
+func (l *List) unlink(n *listNode) {
+	if n.pre == nil {
+		l.first = n.nxt
+	} else {
+		n.pre.nxt = n.nxt
+	}
+	if n.nxt == nil {
+		l.last = n.pre
+	} else {
+		n.nxt.pre = n.pre
+	}
+	n.pre = nil
+	n.nxt = nil
+	l.count--
+}
+
+// linkRange : put nodes first..last before at, at == nil means back
+//go:nosplit
+func (l *List) linkRange(first, last, at *listNode, count int) {
+	var pre *listNode
+	if at == nil {
+		pre = l.last
+		l.last = last
+	} else {
+		pre = at.pre
+		at.pre = last
+	}
+	if pre == nil {
+		l.first = first
+	} else {
+		pre.nxt = first
+	}
+	first.pre = pre
+	last.nxt = at
+	l.count += count
+}
+
+//go:nosplit
+func (l *List) owns(it ListIterator) bool {
+	return it.root == l && it.node != nil && it.Valid()
+}
+
+//MoveToFront : move node of it to front, it stays valid
+func (l *List) MoveToFront(it ListIterator) bool {
+	if !l.owns(it) {
+		return false
+	}
+	if l.first != it.node {
+		l.unlink(it.node)
+		l.linkRange(it.node, it.node, l.first, 1)
+	}
+	return true
+}
+
+//MoveToBack : move node of it to back, it stays valid
+func (l *List) MoveToBack(it ListIterator) bool {
+	if !l.owns(it) {
+		return false
+	}
+	if l.last != it.node {
+		l.unlink(it.node)
+		l.linkRange(it.node, it.node, nil, 1)
+	}
+	return true
+}
+
+//MoveBefore : move node of it front of mark
+func (l *List) MoveBefore(it, mark ListIterator) bool {
+	if !l.owns(it) || !l.owns(mark) {
+		return false
+	}
+	if it.node != mark.node && it.node.nxt != mark.node {
+		l.unlink(it.node)
+		l.linkRange(it.node, it.node, mark.node, 1)
+	}
+	return true
+}
+
+//MoveAfter : move node of it back of mark
+func (l *List) MoveAfter(it, mark ListIterator) bool {
+	if !l.owns(it) || !l.owns(mark) {
+		return false
+	}
+	if it.node != mark.node && mark.node.nxt != it.node {
+		l.unlink(it.node)
+		l.linkRange(it.node, it.node, mark.node.nxt, 1)
+	}
+	return true
+}
+
+//Splice : move nodes [first, last) of other front of pos, last may be other.End(), pos may be l.End().
+//No node is allocated; moving the whole other list is O(1), a part of it is O(n) to count the nodes.
+//Iterators of moved nodes are not valid in other any more, get new ones from l
+func (l *List) Splice(pos ListIterator, other *List, first, last ListIterator) bool {
+	if pos.root != l || !pos.Valid() || first.root != other || !first.Valid() || last.root != other || !last.Valid() {
+		return false
+	}
+	if first.node == nil || first.node == last.node {
+		return true
+	}
+	if first.node == other.first && last.node == nil && other != l {
+		l.adopt(other)
+		l.linkRange(other.first, other.last, pos.node, other.count)
+		other.first = nil
+		other.last = nil
+		other.count = 0
+		return true
+	}
+	count := 0
+	var tail *listNode
+	for n := first.node; n != last.node; n = n.nxt {
+		if n == nil || (other == l && n == pos.node) {
+			return false
+		}
+		tail = n
+		count++
+	}
+	if first.node.pre == nil {
+		other.first = last.node
+	} else {
+		first.node.pre.nxt = last.node
+	}
+	if last.node == nil {
+		other.last = first.node.pre
+	} else {
+		last.node.pre = first.node.pre
+	}
+	other.count -= count
+	if other != l {
+		owner := l.cell()
+		for n := first.node; n != last.node; n = n.nxt {
+			n.owner = owner
+		}
+	}
+	l.linkRange(first.node, tail, pos.node, count)
+	return true
+}
+
+// adopt : all nodes of other belong to l now, by joining the owner cells, other gets a new cell
+//go:nosplit
+func (l *List) adopt(other *List) {
+	mine, theirs := l.cell(), other.cell()
+	if theirs.rank > mine.rank {
+		mine, theirs = theirs, mine
+	}
+	theirs.up = mine
+	theirs.list = nil
+	if theirs.rank == mine.rank {
+		mine.rank++
+	}
+	mine.list = l
+	l.owner = mine
+	other.owner = nil
+}
+
+// mergeChain : merge two sorted chains linked by nxt, a goes first on equal values
+func mergeChain(a, b *listNode, compaire func(a, b interface{}) int) *listNode {
+	var head listNode
+	tail := &head
+	for a != nil && b != nil {
+		if compaire(b.value, a.value) < 0 {
+			tail.nxt = b
+			b = b.nxt
+		} else {
+			tail.nxt = a
+			a = a.nxt
+		}
+		tail = tail.nxt
+	}
+	if a == nil {
+		tail.nxt = b
+	} else {
+		tail.nxt = a
+	}
+	return head.nxt
+}
+
+// relink : rebuild pre, owner and last after nxt changed
+//go:nosplit
+func (l *List) relink(first *listNode) {
+	l.first = first
+	owner := l.cell()
+	var pre *listNode
+	for n := first; n != nil; n = n.nxt {
+		n.pre = pre
+		n.owner = owner
+		pre = n
+	}
+	l.last = pre
+}
+
+//Sort : stable merge sort, nodes are relinked and values are not moved.
+//compaire is the same as Map.Init, nil means OrderedCompaire
+func (l *List) Sort(compaire func(a, b interface{}) int) {
+	if l.count < 2 {
+		return
+	}
+	if compaire == nil {
+		compaire = OrderedCompaire
+	}
+	// bins[i] 为 2^i 个有序节点, 序号大的在前
+	var bins [64]*listNode
+	for n := l.first; n != nil; {
+		carry := n
+		n = n.nxt
+		carry.nxt = nil
+		i := 0
+		for ; bins[i] != nil; i++ {
+			carry = mergeChain(bins[i], carry, compaire)
+			bins[i] = nil
+		}
+		bins[i] = carry
+	}
+	var ret *listNode
+	for _, bin := range bins {
+		if bin != nil {
+			ret = mergeChain(bin, ret, compaire)
+		}
+	}
+	l.relink(ret)
+}
+
+//Merge : merge sorted other into sorted l, other is empty after that.
+//Values of l go first when equal
+func (l *List) Merge(other *List, compaire func(a, b interface{}) int) {
+	if other == l || other.count == 0 {
+		return
+	}
+	if compaire == nil {
+		compaire = OrderedCompaire
+	}
+	count := l.count + other.count
+	l.relink(mergeChain(l.first, other.first, compaire))
+	l.count = count
+	other.first = nil
+	other.last = nil
+	other.count = 0
+}
+
+//Find : first node which pred return true, or End
+func (l *List) Find(pred func(value interface{}) bool) ListIterator {
+	for n := l.first; n != nil; n = n.nxt {
+		if pred(n.value) {
+			return l.iterator(n)
+		}
+	}
+	return l.End()
+}
+
+//RemoveIf : remove all nodes which pred return true, return removed count
+func (l *List) RemoveIf(pred func(value interface{}) bool) int {
+	removed := 0
+	for n := l.first; n != nil; {
+		nxt := n.nxt
+		if pred(n.value) {
+			l.unlink(n)
+			l.freenode(n)
+			removed++
+		}
+		n = nxt
+	}
+	return removed
+}
+
+//Filter : new list of values which pred return true
+func (l *List) Filter(pred func(value interface{}) bool) *List {
+	ret := &List{}
+	for n := l.first; n != nil; n = n.nxt {
+		if pred(n.value) {
+			ret.PushBack(n.value)
+		}
+	}
+	return ret
+}
+
+//Unique : remove consecutive equal values but the first, return removed count.
+//nil equal means reflect.DeepEqual
+func (l *List) Unique(equal func(a, b interface{}) bool) int {
+	if equal == nil {
+		equal = reflect.DeepEqual
+	}
+	removed := 0
+	for n := l.first; n != nil && n.nxt != nil; {
+		if nxt := n.nxt; equal(n.value, nxt.value) {
+			l.unlink(nxt)
+			l.freenode(nxt)
+			removed++
+		} else {
+			n = nxt
+		}
+	}
+	return removed
+}
+
+//Reverse : reverse the list in place, iterators stay valid
+func (l *List) Reverse() {
+	for n := l.first; n != nil; n = n.pre {
+		n.pre, n.nxt = n.nxt, n.pre
+	}
+	l.first, l.last = l.last, l.first
+}
+
+//Rotate : make the node at index n the front, negative n counts from back
+func (l *List) Rotate(n int) {
+	if l.count < 2 {
+		return
+	}
+	n %= l.count
+	if n < 0 {
+		n += l.count
+	}
+	if n == 0 {
+		return
+	}
+	var front *listNode
+	if n < l.count/2 {
+		front = l.first
+		for i := 0; i < n; i++ {
+			front = front.nxt
+		}
+	} else {
+		front = l.last
+		for i := l.count - 1; i > n; i-- {
+			front = front.pre
+		}
+	}
+	// 首尾相接, 再从 front 处断开
+	l.last.nxt = l.first
+	l.first.pre = l.last
+	l.first = front
+	l.last = front.pre
+	l.last.nxt = nil
+	front.pre = nil
+}
+
+//Clone : new list with the same values
+func (l *List) Clone() *List {
+	ret := &List{}
+	for n := l.first; n != nil; n = n.nxt {
+		ret.PushBack(n.value)
+	}
+	return ret
+}
+
+//nodeAt : walk from the nearer end
+func (l *List) nodeAt(i int) *listNode {
+	if i < 0 || i >= l.count {
+		return nil
+	}
+	if i < l.count/2 {
+		n := l.first
+		for ; i > 0; i-- {
+			n = n.nxt
+		}
+		return n
+	}
+	n := l.last
+	for i = l.count - 1 - i; i > 0; i-- {
+		n = n.pre
+	}
+	return n
+}
+
+//At : value at index i, O(min(i, Size()-i))
+func (l *List) At(i int) (interface{}, bool) {
+	if n := l.nodeAt(i); n != nil {
+		return n.value, true
+	}
+	return nil, false
+}
+
+//Iterator : iterator at index i, End if out of range
+func (l *List) Iterator(i int) ListIterator {
+	return l.iterator(l.nodeAt(i))
+}
+
+//InsertAt : insert value so it is at index i, i == Size() means back
+func (l *List) InsertAt(i int, value interface{}) (ListIterator, bool) {
+	if i == l.count {
+		return l.PushBack(value), true
+	}
+	at := l.nodeAt(i)
+	if at == nil {
+		return l.End(), false
+	}
+	n := l.newnode(nil, nil, value)
+	l.linkRange(n, n, at, 1)
+	return l.iterator(n), true
+}
+
+//RemoveAt : remove value at index i
+func (l *List) RemoveAt(i int) (interface{}, bool) {
+	n := l.nodeAt(i)
+	if n == nil {
+		return nil, false
+	}
+	ret := n.value
+	l.unlink(n)
+	l.freenode(n)
+	return ret, true
+}
+
+//Index : index of the node, Size() for End, -1 if not valid. O(i)
+func (i ListIterator) Index() int {
+	if !i.Valid() {
+		return -1
+	}
+	if i.node == nil {
+		return i.root.count
+	}
+	ret := 0
+	for n := i.node.pre; n != nil; n = n.pre {
+		ret++
+	}
+	return ret
+}
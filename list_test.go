@@ -0,0 +1,78 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func checkList(t *testing.T, l *List, want []int) {
+	t.Helper()
+	if l.Size() != len(want) {
+		t.Fatalf("size %d, want %d", l.Size(), len(want))
+	}
+	i := 0
+	for it := l.Front(); !it.IsEnd(); it = it.Back() {
+		if !it.Valid() {
+			t.Fatalf("iterator %d of own list is not valid", i)
+		}
+		if v, _ := it.Value(); v != want[i] {
+			t.Fatalf("item %d is %v, want %d", i, v, want[i])
+		}
+		i++
+	}
+	i = len(want) - 1
+	for it := l.Back(); !it.IsEnd(); it = it.Front() {
+		if v, _ := it.Value(); v != want[i] {
+			t.Fatalf("backward item %d is %v, want %d", i, v, want[i])
+		}
+		i--
+	}
+}
+
+func TestListSpliceOwner(t *testing.T) {
+	lists := make([]List, 4)
+	want := make([][]int, len(lists))
+	next := 0
+	for step := 0; step < 5000; step++ {
+		a, b := rand.Intn(len(lists)), rand.Intn(len(lists))
+		switch rand.Intn(4) {
+		case 0:
+			lists[a].PushBack(next)
+			want[a] = append(want[a], next)
+			next++
+		case 1:
+			// 整表移动
+			if a == b {
+				continue
+			}
+			old := lists[b].Front()
+			if !lists[a].Splice(lists[a].End(), &lists[b], lists[b].Front(), lists[b].End()) {
+				t.Fatalf("whole splice failed")
+			}
+			if !old.IsEnd() && old.Valid() {
+				t.Fatalf("iterator of moved node still valid in its old list")
+			}
+			want[a] = append(want[a], want[b]...)
+			want[b] = nil
+		case 2:
+			// 移动第一个节点
+			if a == b || lists[b].Size() == 0 {
+				continue
+			}
+			lists[a].Splice(lists[a].Front(), &lists[b], lists[b].Front(), lists[b].Front().Back())
+			want[a] = append([]int{want[b][0]}, want[a]...)
+			want[b] = want[b][1:]
+		case 3:
+			if lists[a].Size() == 0 {
+				continue
+			}
+			lists[a].PopFront()
+			want[a] = want[a][1:]
+		}
+	}
+	for i := range lists {
+		checkList(t, &lists[i], want[i])
+	}
+}
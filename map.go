@@ -2,6 +2,8 @@
 
 package goinline
 
+import "unsafe"
+
 // MapIterator
 type MapIterator struct {
 	node *RBTnode
@@ -39,10 +41,19 @@ func (it MapIterator) Value() *RBTpaire {
 	return it.node.Get()
 }
 
+// MapObserver : callbacks fired after the map changed, nil callback is skipped
+type MapObserver struct {
+	OnInsert func(key, value interface{})
+	OnUpdate func(key, old, value interface{})
+	OnErase  func(key, value interface{})
+}
+
 // Map by rbtree
 type Map struct {
-	tree RBtree
-	size uint64
+	tree      RBtree
+	size      uint64
+	observers []*MapObserver
+	pool      *NodePool
 }
 
 // Size : items count
@@ -56,6 +67,7 @@ func (m *Map) Size() uint64 {
 //         if a < b :=>    ret < 0
 //         if a > b :=>    ret > 0
 //         if a == b :=>   ret = 0
+// nil compaire means OrderedCompaire, a zero Map uses it too
 //go:nosplit
 func (m *Map) Init(compaire func(a, b interface{}) int) *Map {
 	m.tree.Init(compaire)
@@ -63,7 +75,55 @@ func (m *Map) Init(compaire func(a, b interface{}) int) *Map {
 	return m
 }
 
-// Clear
+// SetPool : take nodes from pool and give erased nodes back, nil means allocate every node.
+// Iterators of erased nodes must not be used then, the node may be in use again
+func (m *Map) SetPool(pool *NodePool) *Map {
+	m.pool = pool
+	return m
+}
+
+// Observe : register observer, it is called for Set, Erase, Remove and Clear
+func (m *Map) Observe(o *MapObserver) {
+	if o != nil {
+		m.observers = append(m.observers, o)
+	}
+}
+
+// Unobserve : unregister observer
+func (m *Map) Unobserve(o *MapObserver) {
+	for i, v := range m.observers {
+		if v == o {
+			m.observers = append(m.observers[:i:i], m.observers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *Map) notifyInsert(key, value interface{}) {
+	for _, o := range m.observers {
+		if o.OnInsert != nil {
+			o.OnInsert(key, value)
+		}
+	}
+}
+
+func (m *Map) notifyUpdate(key, old, value interface{}) {
+	for _, o := range m.observers {
+		if o.OnUpdate != nil {
+			o.OnUpdate(key, old, value)
+		}
+	}
+}
+
+func (m *Map) notifyErase(key, value interface{}) {
+	for _, o := range m.observers {
+		if o.OnErase != nil {
+			o.OnErase(key, value)
+		}
+	}
+}
+
+// Clear : erase all items, OnErase is fired for every item
 func (m *Map) Clear() {
 	for m.size > 0 {
 		m.Erase(m.Begin())
@@ -97,6 +157,12 @@ func (m *Map) Erase(it MapIterator) {
 	if it.node != nil && it.node.valid {
 		m.tree.Remove(it.node)
 		m.size--
+		if len(m.observers) > 0 {
+			m.notifyErase(it.node.Value.first, it.node.Value.Value)
+		}
+		if m.pool != nil {
+			m.pool.putTreeNode(it.node)
+		}
 	}
 }
 
@@ -109,14 +175,26 @@ func (m *Map) Remove(key interface{}) {
 func (m *Map) Set(key, value interface{}) MapIterator {
 	isparent, node := m.tree.Find(key)
 	if !isparent && node != nil {
-		node.Get().Value = value
+		old := node.Value.Value
+		node.Value.Value = value
+		if len(m.observers) > 0 {
+			m.notifyUpdate(node.Value.first, old, value)
+		}
 		return MapIterator{node}
 	}
-	newnode := (&RBTnode{}).init(colorRed)
+	var newnode *RBTnode
+	if m.pool == nil {
+		newnode = (&RBTnode{}).init(colorRed)
+	} else {
+		newnode = m.pool.getTreeNode().init(colorRed)
+	}
 	newnode.Value.first = key
 	newnode.Value.Value = value
 	m.tree.Insert(node, newnode)
 	m.size++
+	if len(m.observers) > 0 {
+		m.notifyInsert(key, value)
+	}
 	return MapIterator{newnode}
 }
 
@@ -127,3 +205,20 @@ func (m *Map) Find(key interface{}) MapIterator {
 	}
 	return MapIterator{nil}
 }
+
+// LowerBound : first item which key >= key
+func (m *Map) LowerBound(key interface{}) MapIterator {
+	return MapIterator{m.tree.LowerBound(key)}
+}
+
+// UpperBound : first item which key > key
+func (m *Map) UpperBound(key interface{}) MapIterator {
+	return MapIterator{m.tree.UpperBound(key)}
+}
+
+// Stats : tree shape and memory estimate, O(n)
+func (m *Map) Stats() RBTstats {
+	st := m.tree.Stats()
+	st.MemoryBytes += uint64(unsafe.Sizeof(*m))
+	return st
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import "reflect"
+
+// DiffKind : kind of difference
+type DiffKind int8
+
+const (
+	// DiffAdded : key only in b
+	DiffAdded DiffKind = iota + 1
+	// DiffRemoved : key only in a
+	DiffRemoved
+	// DiffChanged : key in both, value not equal
+	DiffChanged
+)
+
+// MapDiffEntry : one difference, Old is the value in a, Value is the value in b
+type MapDiffEntry struct {
+	Kind  DiffKind
+	Key   interface{}
+	Old   interface{}
+	Value interface{}
+}
+
+// MapDiff : differences grouped by kind, each in key order
+type MapDiff struct {
+	Added   []MapDiffEntry
+	Removed []MapDiffEntry
+	Changed []MapDiffEntry
+}
+
+// Diff : differences from a to b
+func Diff(a, b *Map, equal func(x, y interface{}) bool) MapDiff {
+	var ret MapDiff
+	DiffFunc(a, b, equal, func(e MapDiffEntry) bool {
+		switch e.Kind {
+		case DiffAdded:
+			ret.Added = append(ret.Added, e)
+		case DiffRemoved:
+			ret.Removed = append(ret.Removed, e)
+		default:
+			ret.Changed = append(ret.Changed, e)
+		}
+		return false
+	})
+	return ret
+}
+
+// DiffFunc : walk a and b once in key order, handler gets every difference, if handler return true, then walk end.
+// Both maps must be ordered by the same compaire, the one of a is used. nil equal means reflect.DeepEqual
+func DiffFunc(a, b *Map, equal func(x, y interface{}) bool, handler func(e MapDiffEntry) bool) {
+	if handler == nil {
+		return
+	}
+	if equal == nil {
+		equal = reflect.DeepEqual
+	}
+	compaire := a.tree.compairer()
+	x, y := a.Begin(), b.Begin()
+	for !x.IsEnd() || !y.IsEnd() {
+		var e MapDiffEntry
+		cmp := 0
+		if x.IsEnd() {
+			cmp = 1
+		} else if y.IsEnd() {
+			cmp = -1
+		} else {
+			cmp = compaire(x.node.Value.first, y.node.Value.first)
+		}
+		switch {
+		case cmp < 0:
+			e = MapDiffEntry{DiffRemoved, x.node.Value.first, x.node.Value.Value, nil}
+			x = x.Next()
+		case cmp > 0:
+			e = MapDiffEntry{DiffAdded, y.node.Value.first, nil, y.node.Value.Value}
+			y = y.Next()
+		default:
+			e = MapDiffEntry{DiffChanged, y.node.Value.first, x.node.Value.Value, y.node.Value.Value}
+			x, y = x.Next(), y.Next()
+			if equal(e.Old, e.Value) {
+				continue
+			}
+		}
+		if handler(e) {
+			return
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// ErrBadCursor : Page got a cursor it did not make
+var ErrBadCursor = errors.New("goinline: bad page cursor")
+
+// pageCursorVersion : first byte of every cursor, so a cursor of an empty encoded key is not ""
+const pageCursorVersion = 1
+
+// Page : at most limit items after cursor, and the cursor of the next page, "" when no more.
+// The cursor is the encoded last key, so it stays correct when items were set or erased between calls.
+// Empty cursor means the first page
+func (m *Map) Page(cursor string, limit int, codec Codec) ([]RBTpaire, string, error) {
+	if limit <= 0 {
+		return nil, cursor, nil
+	}
+	it := m.Begin()
+	if len(cursor) > 0 {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(raw) == 0 || raw[0] != pageCursorVersion {
+			return nil, "", ErrBadCursor
+		}
+		after, err := codec.Decode(raw[1:])
+		if err != nil {
+			return nil, "", ErrBadCursor
+		}
+		it = m.UpperBound(after)
+	}
+	var page []RBTpaire
+	for ; !it.IsEnd() && len(page) < limit; it = it.Next() {
+		page = append(page, *it.Value())
+	}
+	if it.IsEnd() || len(page) == 0 {
+		return page, "", nil
+	}
+	raw, err := codec.Encode(page[len(page)-1].first)
+	if err != nil {
+		return nil, "", err
+	}
+	raw = append([]byte{pageCursorVersion}, raw...)
+	return page, base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+type viewBound struct {
+	key       interface{}
+	inclusive bool
+	set       bool
+}
+
+// MapView : live window of a Map restricted to a key range, maybe in reverse order.
+// It does not copy the map, changes of the map are seen at once
+type MapView struct {
+	m          *Map
+	lo         viewBound
+	hi         viewBound
+	descending bool
+}
+
+// MapViewIterator
+type MapViewIterator struct {
+	view *MapView
+	node *RBTnode
+}
+
+// HeadMap : items which key < hi, or <= hi if inclusive
+func (m *Map) HeadMap(hi interface{}, inclusive bool) *MapView {
+	return (&MapView{m: m}).HeadMap(hi, inclusive)
+}
+
+// TailMap : items which key > lo, or >= lo if inclusive
+func (m *Map) TailMap(lo interface{}, inclusive bool) *MapView {
+	return (&MapView{m: m}).TailMap(lo, inclusive)
+}
+
+// SubMap : items between lo and hi
+func (m *Map) SubMap(lo interface{}, loInclusive bool, hi interface{}, hiInclusive bool) *MapView {
+	return (&MapView{m: m}).SubMap(lo, loInclusive, hi, hiInclusive)
+}
+
+// Descending : all items in reverse order
+func (m *Map) Descending() *MapView {
+	return &MapView{m: m, descending: true}
+}
+
+// HeadMap : items before hi in view order
+func (v *MapView) HeadMap(hi interface{}, inclusive bool) *MapView {
+	ret := *v
+	if v.descending {
+		ret.lo = v.tighter(v.lo, viewBound{hi, inclusive, true}, 1)
+	} else {
+		ret.hi = v.tighter(v.hi, viewBound{hi, inclusive, true}, -1)
+	}
+	return &ret
+}
+
+// TailMap : items after lo in view order
+func (v *MapView) TailMap(lo interface{}, inclusive bool) *MapView {
+	ret := *v
+	if v.descending {
+		ret.hi = v.tighter(v.hi, viewBound{lo, inclusive, true}, -1)
+	} else {
+		ret.lo = v.tighter(v.lo, viewBound{lo, inclusive, true}, 1)
+	}
+	return &ret
+}
+
+// SubMap : items from lo to hi in view order
+func (v *MapView) SubMap(lo interface{}, loInclusive bool, hi interface{}, hiInclusive bool) *MapView {
+	return v.TailMap(lo, loInclusive).HeadMap(hi, hiInclusive)
+}
+
+// Descending : same range in reverse order
+func (v *MapView) Descending() *MapView {
+	ret := *v
+	ret.descending = !v.descending
+	return &ret
+}
+
+// tighter : the narrower bound, sign 1 for lower bound, -1 for upper bound
+func (v *MapView) tighter(old, bound viewBound, sign int) viewBound {
+	if !old.set {
+		return bound
+	}
+	cmp := v.m.tree.compairer()(bound.key, old.key) * sign
+	if cmp > 0 || (cmp == 0 && !bound.inclusive) {
+		return bound
+	}
+	return old
+}
+
+// InRange : key is inside the view
+func (v *MapView) InRange(key interface{}) bool {
+	compaire := v.m.tree.compairer()
+	if v.lo.set {
+		if cmp := compaire(key, v.lo.key); cmp < 0 || (cmp == 0 && !v.lo.inclusive) {
+			return false
+		}
+	}
+	if v.hi.set {
+		if cmp := compaire(key, v.hi.key); cmp > 0 || (cmp == 0 && !v.hi.inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *MapView) first() *RBTnode {
+	if !v.lo.set {
+		return v.m.tree.Begin()
+	}
+	if v.lo.inclusive {
+		return v.m.tree.LowerBound(v.lo.key)
+	}
+	return v.m.tree.UpperBound(v.lo.key)
+}
+
+func (v *MapView) last() *RBTnode {
+	if !v.hi.set {
+		return v.m.tree.Rbegin()
+	}
+	var next *RBTnode
+	if v.hi.inclusive {
+		next = v.m.tree.UpperBound(v.hi.key)
+	} else {
+		next = v.m.tree.LowerBound(v.hi.key)
+	}
+	if next == nil {
+		return v.m.tree.Rbegin()
+	}
+	return next.Pre()
+}
+
+func (v *MapView) iterator(node *RBTnode) MapViewIterator {
+	if node != nil && !v.InRange(node.Value.first) {
+		node = nil
+	}
+	return MapViewIterator{v, node}
+}
+
+// Begin : first item in view order
+func (v *MapView) Begin() MapViewIterator {
+	if v.descending {
+		return v.iterator(v.last())
+	}
+	return v.iterator(v.first())
+}
+
+// End
+//go:nosplit
+func (v *MapView) End() MapViewIterator {
+	return MapViewIterator{v, nil}
+}
+
+// Find : item by key, End if not found or out of range
+func (v *MapView) Find(key interface{}) MapViewIterator {
+	if !v.InRange(key) {
+		return v.End()
+	}
+	return MapViewIterator{v, v.m.Find(key).node}
+}
+
+// Size : items count, O(n) for a bounded view
+func (v *MapView) Size() uint64 {
+	if !v.lo.set && !v.hi.set {
+		return v.m.Size()
+	}
+	var ret uint64
+	for it := v.Begin(); !it.IsEnd(); it = it.Next() {
+		ret++
+	}
+	return ret
+}
+
+// Set : set item in the map, false if key out of range
+func (v *MapView) Set(key, value interface{}) (MapViewIterator, bool) {
+	if !v.InRange(key) {
+		return v.End(), false
+	}
+	return MapViewIterator{v, v.m.Set(key, value).node}, true
+}
+
+// Remove : remove item from the map, false if key out of range or not found
+func (v *MapView) Remove(key interface{}) bool {
+	it := v.Find(key)
+	if it.IsEnd() {
+		return false
+	}
+	v.m.Erase(MapIterator{it.node})
+	return true
+}
+
+// IsEnd
+//go:nosplit
+func (it MapViewIterator) IsEnd() bool {
+	return it.node == nil
+}
+
+// Next : next Iterator in view order
+func (it MapViewIterator) Next() MapViewIterator {
+	if it.node == nil {
+		return it
+	}
+	if it.view.descending {
+		return it.view.iterator(it.node.Pre())
+	}
+	return it.view.iterator(it.node.Next())
+}
+
+// Value return node data
+func (it MapViewIterator) Value() *RBTpaire {
+	return it.node.Get()
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import "container/heap"
+
+// MergePolicy : which item is yielded when several maps hold the same key
+type MergePolicy int8
+
+const (
+	// MergeFirstWins : item of the first map in argument order
+	MergeFirstWins MergePolicy = iota
+	// MergeLastWins : item of the last map in argument order
+	MergeLastWins
+	// MergeAll : every item, in argument order
+	MergeAll
+)
+
+type mergeCursor struct {
+	it     MapIterator
+	source int
+}
+
+type mergeHeap struct {
+	cursors  []mergeCursor
+	compaire func(a, b interface{}) int
+}
+
+func (h *mergeHeap) Len() int { return len(h.cursors) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := &h.cursors[i], &h.cursors[j]
+	if cmp := h.compaire(a.it.node.Value.first, b.it.node.Value.first); cmp != 0 {
+		return cmp < 0
+	}
+	return a.source < b.source
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+
+func (h *mergeHeap) Push(x interface{}) { h.cursors = append(h.cursors, x.(mergeCursor)) }
+
+func (h *mergeHeap) Pop() interface{} {
+	last := len(h.cursors) - 1
+	ret := h.cursors[last]
+	h.cursors = h.cursors[:last]
+	return ret
+}
+
+// MergeIterator : iterate several maps as one in key order, without copying them
+type MergeIterator struct {
+	policy  MergePolicy
+	heap    mergeHeap
+	group   []mergeCursor
+	current mergeCursor
+}
+
+// NewMergeIterator : maps must be ordered by compatible compaire, the one of maps[0] is used
+func NewMergeIterator(policy MergePolicy, maps ...*Map) *MergeIterator {
+	ret := &MergeIterator{policy: policy}
+	if len(maps) > 0 {
+		ret.heap.compaire = maps[0].tree.compairer()
+	}
+	for i, m := range maps {
+		if it := m.Begin(); !it.IsEnd() {
+			ret.heap.cursors = append(ret.heap.cursors, mergeCursor{it, i})
+		}
+	}
+	heap.Init(&ret.heap)
+	ret.settle()
+	return ret
+}
+
+// IsEnd
+//go:nosplit
+func (it *MergeIterator) IsEnd() bool {
+	return it.current.it.node == nil
+}
+
+// Value : current k-v paire
+func (it *MergeIterator) Value() *RBTpaire {
+	return it.current.it.Value()
+}
+
+// Source : index of the map the current item comes from
+//go:nosplit
+func (it *MergeIterator) Source() int {
+	return it.current.source
+}
+
+// Next : move to next item
+func (it *MergeIterator) Next() {
+	if !it.IsEnd() {
+		it.settle()
+	}
+}
+
+func (it *MergeIterator) settle() {
+	h := &it.heap
+	if h.Len() == 0 {
+		it.current = mergeCursor{MapIterator{nil}, -1}
+		return
+	}
+	it.group = append(it.group[:0], heap.Pop(h).(mergeCursor))
+	if it.policy != MergeAll {
+		key := it.group[0].it.node.Value.first
+		for h.Len() > 0 && h.compaire(h.cursors[0].it.node.Value.first, key) == 0 {
+			it.group = append(it.group, heap.Pop(h).(mergeCursor))
+		}
+	}
+	// 同键按 source 升序出堆
+	if it.policy == MergeLastWins {
+		it.current = it.group[len(it.group)-1]
+	} else {
+		it.current = it.group[0]
+	}
+	for _, c := range it.group {
+		if c.it = c.it.Next(); !c.it.IsEnd() {
+			heap.Push(h, c)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+const cacheLineSize = 64
+
+type mpmcCell struct {
+	seq   uint64
+	value interface{}
+}
+
+// MPMCQueue : bounded lock-free multi-producer multi-consumer queue (Vyukov sequence ring).
+// Unlike the other containers the zero value has no ring and is not usable, make it by NewMPMCQueue
+type MPMCQueue struct {
+	enqueue uint64 // 放在首位, 32 位平台上也 8 字节对齐
+	_       [cacheLineSize - 8]byte
+	dequeue uint64
+	_       [cacheLineSize - 8]byte
+	mask    uint64
+	cells   []mpmcCell
+}
+
+// NewMPMCQueue : the only constructor, capacity is rounded up to a power of 2, at least 2
+func NewMPMCQueue(capacity int) *MPMCQueue {
+	size := 2
+	for size < capacity {
+		size <<= 1
+	}
+	q := &MPMCQueue{mask: uint64(size - 1), cells: make([]mpmcCell, size)}
+	for i := range q.cells {
+		q.cells[i].seq = uint64(i)
+	}
+	return q
+}
+
+// Cap : max items count
+//go:nosplit
+func (q *MPMCQueue) Cap() int {
+	return len(q.cells)
+}
+
+// Size : items count, only a snapshot when other goroutines are working
+func (q *MPMCQueue) Size() int {
+	dequeue := atomic.LoadUint64(&q.dequeue)
+	enqueue := atomic.LoadUint64(&q.enqueue)
+	if enqueue < dequeue {
+		return 0
+	}
+	return int(enqueue - dequeue)
+}
+
+// TryPush : false if full
+func (q *MPMCQueue) TryPush(value interface{}) bool {
+	pos := atomic.LoadUint64(&q.enqueue)
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := atomic.LoadUint64(&cell.seq)
+		if dif := int64(seq - pos); dif == 0 {
+			if atomic.CompareAndSwapUint64(&q.enqueue, pos, pos+1) {
+				cell.value = value
+				atomic.StoreUint64(&cell.seq, pos+1)
+				return true
+			}
+		} else if dif < 0 {
+			return false
+		}
+		pos = atomic.LoadUint64(&q.enqueue)
+	}
+}
+
+// TryPop : false if empty
+func (q *MPMCQueue) TryPop() (interface{}, bool) {
+	pos := atomic.LoadUint64(&q.dequeue)
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := atomic.LoadUint64(&cell.seq)
+		if dif := int64(seq - (pos + 1)); dif == 0 {
+			if atomic.CompareAndSwapUint64(&q.dequeue, pos, pos+1) {
+				value := cell.value
+				cell.value = nil
+				atomic.StoreUint64(&cell.seq, pos+q.mask+1)
+				return value, true
+			}
+		} else if dif < 0 {
+			return nil, false
+		}
+		pos = atomic.LoadUint64(&q.dequeue)
+	}
+}
+
+// Push : wait for room until ctx done
+func (q *MPMCQueue) Push(ctx context.Context, value interface{}) error {
+	for spin := 0; !q.TryPush(value); spin++ {
+		if err := backoff(ctx, spin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pop : wait for an item until ctx done
+func (q *MPMCQueue) Pop(ctx context.Context) (interface{}, error) {
+	for spin := 0; ; spin++ {
+		if value, ok := q.TryPop(); ok {
+			return value, nil
+		}
+		if err := backoff(ctx, spin); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff : yield first, then sleep longer and longer up to 1ms
+func backoff(ctx context.Context, spin int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	if spin < 16 {
+		runtime.Gosched()
+		return nil
+	}
+	sleep := time.Microsecond << uint(min(spin-16, 10))
+	timer := time.NewTimer(sleep)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestMPMCQueueTry(t *testing.T) {
+	q := NewMPMCQueue(3)
+	if q.Cap() != 4 {
+		t.Fatalf("cap %d, want 4", q.Cap())
+	}
+	for i := 0; i < 4; i++ {
+		if !q.TryPush(i) {
+			t.Fatalf("push %d into queue of %d items failed", i, q.Size())
+		}
+	}
+	if q.TryPush(4) {
+		t.Fatalf("push into full queue")
+	}
+	for i := 0; i < 4; i++ {
+		if v, ok := q.TryPop(); !ok || v != i {
+			t.Fatalf("pop %v %v, want %d", v, ok, i)
+		}
+	}
+	if _, ok := q.TryPop(); ok || q.Size() != 0 {
+		t.Fatalf("pop from empty queue")
+	}
+}
+
+// TestMPMCQueueConcurrent : run with -race, every item is popped exactly once and each producer's items keep their order
+func TestMPMCQueueConcurrent(t *testing.T) {
+	const producers, consumers, items = 4, 4, 5000
+	q := NewMPMCQueue(64)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < items; i++ {
+				q.Push(ctx, [2]int{p, i})
+			}
+		}(p)
+	}
+	got := make([][]int, consumers)
+	var cwg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		cwg.Add(1)
+		go func(c int) {
+			defer cwg.Done()
+			last := make([]int, producers)
+			for i := range last {
+				last[i] = -1
+			}
+			for {
+				v, _ := q.Pop(ctx)
+				item := v.([2]int)
+				if item[0] < 0 {
+					return
+				}
+				if item[1] <= last[item[0]] {
+					t.Errorf("producer %d item %d after %d", item[0], item[1], last[item[0]])
+				}
+				last[item[0]] = item[1]
+				got[c] = append(got[c], item[0]*items+item[1])
+			}
+		}(c)
+	}
+	wg.Wait()
+	for c := 0; c < consumers; c++ {
+		q.Push(ctx, [2]int{-1, 0})
+	}
+	cwg.Wait()
+	seen := make([]bool, producers*items)
+	for _, values := range got {
+		for _, v := range values {
+			if seen[v] {
+				t.Fatalf("item %d popped twice", v)
+			}
+			seen[v] = true
+		}
+	}
+	for v, ok := range seen {
+		if !ok {
+			t.Fatalf("item %d lost", v)
+		}
+	}
+}
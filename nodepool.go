@@ -0,0 +1,97 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+// NodePool : free list and slab arena for nodes of List and Map.
+// One pool may be shared by several containers, but it is not safe for concurrent use
+type NodePool struct {
+	limit     int
+	slab      int
+	listFree  *listNode // 以 nxt 串起
+	listCount int
+	listSlab  []listNode
+	treeFree  *RBTnode // 以 right 串起
+	treeCount int
+	treeSlab  []RBTnode
+}
+
+// NewNodePool : limit is the max free nodes kept of each kind, 0 means no limit;
+// slab > 1 allocates that many nodes at once when no free node left
+func NewNodePool(limit, slab int) *NodePool {
+	return &NodePool{limit: limit, slab: slab}
+}
+
+// Free : free nodes count of List and Map
+//go:nosplit
+func (p *NodePool) Free() (list, tree int) {
+	return p.listCount, p.treeCount
+}
+
+// Drain : drop all free nodes to the GC
+func (p *NodePool) Drain() {
+	p.listFree = nil
+	p.listCount = 0
+	p.listSlab = nil
+	p.treeFree = nil
+	p.treeCount = 0
+	p.treeSlab = nil
+}
+
+func (p *NodePool) getListNode() *listNode {
+	if n := p.listFree; n != nil {
+		p.listFree = n.nxt
+		p.listCount--
+		n.nxt = nil
+		return n
+	}
+	if p.slab <= 1 {
+		return &listNode{}
+	}
+	if len(p.listSlab) == 0 {
+		p.listSlab = make([]listNode, p.slab)
+	}
+	n := &p.listSlab[0]
+	p.listSlab = p.listSlab[1:]
+	return n
+}
+
+func (p *NodePool) putListNode(n *listNode) {
+	n.reset()
+	if p.limit > 0 && p.listCount >= p.limit {
+		return
+	}
+	n.nxt = p.listFree
+	p.listFree = n
+	p.listCount++
+}
+
+func (p *NodePool) getTreeNode() *RBTnode {
+	if n := p.treeFree; n != nil {
+		p.treeFree = n.right
+		p.treeCount--
+		n.right = nil
+		return n
+	}
+	if p.slab <= 1 {
+		return &RBTnode{}
+	}
+	if len(p.treeSlab) == 0 {
+		p.treeSlab = make([]RBTnode, p.slab)
+	}
+	n := &p.treeSlab[0]
+	p.treeSlab = p.treeSlab[1:]
+	return n
+}
+
+func (p *NodePool) putTreeNode(n *RBTnode) {
+	n.Value = RBTpaire{}
+	n.left = nil
+	n.parent = nil
+	n.right = nil
+	if p.limit > 0 && p.treeCount >= p.limit {
+		return
+	}
+	n.right = p.treeFree
+	p.treeFree = n
+	p.treeCount++
+}
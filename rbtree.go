@@ -2,6 +2,8 @@
 
 package goinline
 
+import "unsafe"
+
 type rbColor int8
 
 const (
@@ -128,17 +130,30 @@ func (t *RBTnode) Next() *RBTnode {
 type RBtree struct {
 	compaire func(a, b interface{}) int
 	root     *RBTnode
+	temp     RBTnode
 }
 
-// Init struct
+// Init struct, nil compaire means OrderedCompaire
 //go:nosplit
 func (rbt *RBtree) Init(compaire func(a, b interface{}) int) *RBtree {
 	rbt.root = nil
 	// 他山之石
+	if compaire == nil {
+		compaire = OrderedCompaire
+	}
 	rbt.compaire = compaire
 	return rbt
 }
 
+// compairer : zero RBtree falls back to OrderedCompaire, never writes the tree so reads stay concurrent
+//go:nosplit
+func (rbt *RBtree) compairer() func(a, b interface{}) int {
+	if rbt.compaire == nil {
+		return OrderedCompaire
+	}
+	return rbt.compaire
+}
+
 //go:nosplit
 func (rbt *RBtree) rotate(current *RBTnode) {
 	for {
@@ -276,7 +291,7 @@ func (rbt *RBtree) removeone(node *RBTnode) {
 	var _tempnode *RBTnode = nil
 
 	if node.isblack() && child == nil {
-		_tempnode = (&RBTnode{}).init(colorBlack)
+		_tempnode = rbt.temp.init(colorBlack)
 		child = _tempnode // 借鸡生蛋
 	}
 	if parent.left == node {
@@ -428,9 +443,10 @@ func (rbt *RBtree) removeCaseN(n *RBTnode) {
 func (rbt *RBtree) Find(key interface{}) (isParent bool, r *RBTnode) {
 	node := rbt.root
 	if node != nil {
+		compaire := rbt.compairer()
 		for {
 			// 可以攻玉
-			cmp := rbt.compaire(key, node.Value.first)
+			cmp := compaire(key, node.Value.first)
 			if cmp == 0 {
 				return false, node
 			}
@@ -464,7 +480,7 @@ func (rbt *RBtree) Insert(parent, node *RBTnode) {
 	if parent == nil {
 		rbt.root = node
 	} else {
-		if rbt.compaire(node.Value.first, parent.Value.first) < 0 {
+		if rbt.compairer()(node.Value.first, parent.Value.first) < 0 {
 			parent.left = node
 		} else {
 			parent.right = node
@@ -492,6 +508,40 @@ func (rbt *RBtree) Remove(node *RBTnode) {
 	node.valid = false
 }
 
+// LowerBound : first node which key >= key
+func (rbt *RBtree) LowerBound(key interface{}) *RBTnode {
+	var ret *RBTnode
+	if rbt.root != nil {
+		compaire := rbt.compairer()
+		for node := rbt.root; node != nil; {
+			if compaire(node.Value.first, key) < 0 {
+				node = node.right
+			} else {
+				ret = node
+				node = node.left
+			}
+		}
+	}
+	return ret
+}
+
+// UpperBound : first node which key > key
+func (rbt *RBtree) UpperBound(key interface{}) *RBTnode {
+	var ret *RBTnode
+	if rbt.root != nil {
+		compaire := rbt.compairer()
+		for node := rbt.root; node != nil; {
+			if compaire(node.Value.first, key) <= 0 {
+				node = node.right
+			} else {
+				ret = node
+				node = node.left
+			}
+		}
+	}
+	return ret
+}
+
 // Begin : Node on tree left
 //go:nosplit
 func (rbt *RBtree) Begin() *RBTnode {
@@ -515,3 +565,54 @@ func (rbt *RBtree) Rbegin() *RBTnode {
 	}
 	return ret
 }
+
+// RBTstats : shape of the tree
+type RBTstats struct {
+	Nodes        uint64
+	Height       int // nodes on the longest root to leaf path
+	BlackHeight  int // black nodes on any root to nil path
+	MinLeafDepth int // depth of the shallowest node without children, root depth is 1
+	MaxLeafDepth int
+	RedNodes     uint64
+	MemoryBytes  uint64 // estimate, nodes only, keys and values are not counted
+}
+
+// Stats : walk the tree, O(n)
+func (rbt *RBtree) Stats() RBTstats {
+	var st RBTstats
+	for node := rbt.root; node != nil; node = node.left {
+		if node.isblack() {
+			st.BlackHeight++
+		}
+	}
+	if rbt.root != nil {
+		rbt.root.stats(1, &st)
+	}
+	st.MemoryBytes = st.Nodes * uint64(unsafe.Sizeof(RBTnode{}))
+	return st
+}
+
+func (t *RBTnode) stats(depth int, st *RBTstats) {
+	st.Nodes++
+	if t.isred() {
+		st.RedNodes++
+	}
+	if depth > st.Height {
+		st.Height = depth
+	}
+	if t.left == nil && t.right == nil {
+		if st.MinLeafDepth == 0 || depth < st.MinLeafDepth {
+			st.MinLeafDepth = depth
+		}
+		if depth > st.MaxLeafDepth {
+			st.MaxLeafDepth = depth
+		}
+		return
+	}
+	if t.left != nil {
+		t.left.stats(depth+1, st)
+	}
+	if t.right != nil {
+		t.right.stats(depth+1, st)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+const ringDequeMinSize = 8
+
+// RingDeque : deque on a growable circular array, zero RingDeque is ready to use.
+// It has the push and pop of List without a node for each item, and At(i) is O(1)
+type RingDeque struct {
+	buf   []interface{} // 长度总是 2 的幂
+	head  int
+	count int
+}
+
+// Size : items count
+//go:nosplit
+func (d *RingDeque) Size() int {
+	return d.count
+}
+
+// Clear : drop all items, keep the buffer
+func (d *RingDeque) Clear() {
+	for i := 0; i < d.count; i++ {
+		d.buf[(d.head+i)&(len(d.buf)-1)] = nil
+	}
+	d.head = 0
+	d.count = 0
+}
+
+func (d *RingDeque) grow() {
+	size := len(d.buf) * 2
+	if size < ringDequeMinSize {
+		size = ringDequeMinSize
+	}
+	buf := make([]interface{}, size)
+	if d.count > 0 {
+		n := copy(buf, d.buf[d.head:])
+		copy(buf[n:], d.buf[:d.head])
+	}
+	d.buf = buf
+	d.head = 0
+}
+
+// PushBack
+func (d *RingDeque) PushBack(value interface{}) {
+	if d.count == len(d.buf) {
+		d.grow()
+	}
+	d.buf[(d.head+d.count)&(len(d.buf)-1)] = value
+	d.count++
+}
+
+// PushFront
+func (d *RingDeque) PushFront(value interface{}) {
+	if d.count == len(d.buf) {
+		d.grow()
+	}
+	d.head = (d.head - 1) & (len(d.buf) - 1)
+	d.buf[d.head] = value
+	d.count++
+}
+
+// PopFront
+func (d *RingDeque) PopFront() (interface{}, bool) {
+	if d.count == 0 {
+		return nil, false
+	}
+	ret := d.buf[d.head]
+	d.buf[d.head] = nil
+	d.head = (d.head + 1) & (len(d.buf) - 1)
+	d.count--
+	return ret, true
+}
+
+// PopBack
+func (d *RingDeque) PopBack() (interface{}, bool) {
+	if d.count == 0 {
+		return nil, false
+	}
+	d.count--
+	i := (d.head + d.count) & (len(d.buf) - 1)
+	ret := d.buf[i]
+	d.buf[i] = nil
+	return ret, true
+}
+
+// At : item at index i from front
+//go:nosplit
+func (d *RingDeque) At(i int) (interface{}, bool) {
+	if i < 0 || i >= d.count {
+		return nil, false
+	}
+	return d.buf[(d.head+i)&(len(d.buf)-1)], true
+}
+
+// Set : replace item at index i from front
+//go:nosplit
+func (d *RingDeque) Set(i int, value interface{}) bool {
+	if i < 0 || i >= d.count {
+		return false
+	}
+	d.buf[(d.head+i)&(len(d.buf)-1)] = value
+	return true
+}
@@ -0,0 +1,240 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import "math/bits"
+
+const (
+	swarLow  = 0x0101010101010101
+	swarHigh = 0x8080808080808080
+)
+
+//go:nosplit
+func load64(s string, i int) uint64 {
+	_ = s[i+7]
+	return uint64(s[i]) | uint64(s[i+1])<<8 | uint64(s[i+2])<<16 | uint64(s[i+3])<<24 |
+		uint64(s[i+4])<<32 | uint64(s[i+5])<<40 | uint64(s[i+6])<<48 | uint64(s[i+7])<<56
+}
+
+// swarZero : high bit set in bytes of x which are 0, the lowest one is exact
+//go:nosplit
+func swarZero(x uint64) uint64 {
+	return (x - swarLow) &^ x & swarHigh
+}
+
+// indexByte : first ch in str, 8 bytes a time
+//go:nosplit
+func indexByte(str string, ch uint8) int {
+	i := 0
+	mask := swarLow * uint64(ch)
+	for ; i+8 <= len(str); i += 8 {
+		if z := swarZero(load64(str, i) ^ mask); z != 0 {
+			return i + bits.TrailingZeros64(z)/8
+		}
+	}
+	for ; i < len(str); i++ {
+		if str[i] == ch {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastIndexByte : last ch in str, 8 bytes a time
+//go:nosplit
+func lastIndexByte(str string, ch uint8) int {
+	i := len(str)
+	mask := swarLow * uint64(ch)
+	for ; i >= 8; i -= 8 {
+		if swarZero(load64(str, i-8)^mask) != 0 {
+			// 高位可能因借位误报, 逐字节确认
+			for k := i - 1; k >= i-8; k-- {
+				if str[k] == ch {
+					return k
+				}
+			}
+		}
+	}
+	for ; i > 0; i-- {
+		if str[i-1] == ch {
+			return i - 1
+		}
+	}
+	return -1
+}
+
+// byteAt : byte i of s, counted from the end if rev
+//go:nosplit
+func byteAt(s string, i int, rev bool) uint8 {
+	if rev {
+		return s[len(s)-1-i]
+	}
+	return s[i]
+}
+
+// twoWay : critical factorization of a pattern for the Crochemore-Perrin Two-Way search
+type twoWay struct {
+	ell      int
+	per      int
+	periodic bool
+}
+
+// maxSuffix : maximal suffix of x and its period, by byte order or by reversed byte order
+func maxSuffix(x string, rev, reversed bool) (int, int) {
+	ms, j, k, p := -1, 0, 1, 1
+	for j+k < len(x) {
+		a, b := byteAt(x, j+k, rev), byteAt(x, ms+k, rev)
+		if reversed {
+			a, b = b, a
+		}
+		if a < b {
+			j += k
+			k = 1
+			p = j - ms
+		} else if a == b {
+			if k != p {
+				k++
+			} else {
+				j += p
+				k = 1
+			}
+		} else {
+			ms = j
+			j = ms + 1
+			k = 1
+			p = 1
+		}
+	}
+	return ms, p
+}
+
+//go:nosplit
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (t *twoWay) init(x string, rev bool) {
+	ms1, p1 := maxSuffix(x, rev, false)
+	ms2, p2 := maxSuffix(x, rev, true)
+	t.ell, t.per = ms2, p2
+	if ms1 > ms2 {
+		t.ell, t.per = ms1, p1
+	}
+	t.periodic = true
+	for i := 0; i <= t.ell; i++ {
+		if byteAt(x, i, rev) != byteAt(x, i+t.per, rev) {
+			t.periodic = false
+			break
+		}
+	}
+	if !t.periodic {
+		t.per = max(t.ell+1, len(x)-t.ell-1) + 1
+	}
+}
+
+// scan : first j in [from, to] which byte j of y is ch, -1 if none
+//go:nosplit
+func scan(y string, ch uint8, from, to int, rev bool) int {
+	if !rev {
+		if k := indexByte(y[from:to+1], ch); k >= 0 {
+			return from + k
+		}
+		return -1
+	}
+	n := len(y)
+	if k := lastIndexByte(y[n-1-to:n-from], ch); k >= 0 {
+		return n - 1 - (n - 1 - to + k)
+	}
+	return -1
+}
+
+// index : Two-Way search of x in y, linear time and no memory, positions are counted from the end if rev
+func (t *twoWay) index(y, x string, rev bool) int {
+	n, m := len(y), len(x)
+	first := byteAt(x, 0, rev)
+	j, memory := 0, -1
+	for j <= n-m {
+		if memory < 0 {
+			// 首字节都不匹配的位置直接跳过
+			if j = scan(y, first, j, n-m, rev); j < 0 {
+				return -1
+			}
+		}
+		i := max(t.ell, memory) + 1
+		for i < m && byteAt(x, i, rev) == byteAt(y, i+j, rev) {
+			i++
+		}
+		if i < m {
+			j += i - t.ell
+			memory = -1
+			continue
+		}
+		i = t.ell
+		for i > memory && byteAt(x, i, rev) == byteAt(y, i+j, rev) {
+			i--
+		}
+		if i <= memory {
+			return j
+		}
+		j += t.per
+		if t.periodic {
+			memory = m - t.per - 1
+		}
+	}
+	return -1
+}
+
+// Searcher : precompiled pattern for repeated StrStr and StrRstr
+type Searcher struct {
+	pattern  string
+	forward  twoWay
+	backward twoWay
+}
+
+// NewSearcher
+func NewSearcher(pattern string) *Searcher {
+	s := &Searcher{pattern: pattern}
+	if len(pattern) > 1 {
+		s.forward.init(pattern, false)
+		s.backward.init(pattern, true)
+	}
+	return s
+}
+
+// Pattern
+//go:nosplit
+func (s *Searcher) Pattern() string {
+	return s.pattern
+}
+
+// Index : same as StrStr(source, pattern)
+func (s *Searcher) Index(source string) int {
+	switch {
+	case len(s.pattern) == 0:
+		return 0
+	case len(s.pattern) > len(source):
+		return -1
+	case len(s.pattern) == 1:
+		return indexByte(source, s.pattern[0])
+	}
+	return s.forward.index(source, s.pattern, false)
+}
+
+// LastIndex : same as StrRstr(source, pattern)
+func (s *Searcher) LastIndex(source string) int {
+	switch {
+	case len(s.pattern) == 0:
+		return len(source)
+	case len(s.pattern) > len(source):
+		return -1
+	case len(s.pattern) == 1:
+		return lastIndexByte(source, s.pattern[0])
+	}
+	if j := s.backward.index(source, s.pattern, true); j >= 0 {
+		return len(source) - j - len(s.pattern)
+	}
+	return -1
+}
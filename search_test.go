@@ -0,0 +1,115 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var searchTests = []struct {
+	source, target string
+}{
+	{"", ""},
+	{"abc", ""},
+	{"", "a"},
+	{"a", "a"},
+	{"a", "b"},
+	{"ab", "abc"},
+	{"abc", "c"},
+	{"abcabc", "a"},
+	{"abcabc", "bc"},
+	{"abcabc", "abc"},
+	{"abcabd", "abd"},
+	{"aaaaaaaaab", "aab"},
+	{"aabaabaabaab", "aabaab"},
+	{"abababababc", "ababc"},
+	{"abababababab", "abab"},
+	{"xxxxxxxxxxxxxxxxxxxxxxxxyz", "xyz"},
+	{"zyxxxxxxxxxxxxxxxxxxxxxxxxx", "zyx"},
+	{"0123456789abcdef0123456789abcdef", "f0"},
+	{"0123456789abcdef0123456789abcdef", "ef"},
+	{"\x80\xff\x80\xff\x80\xff\x81", "\xff\x81"},
+	{"\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff", "\xfe"},
+	{"\x00\x01\x00\x01\x00\x00\x01", "\x00\x00\x01"},
+	{"中文中文字符串", "文字"},
+	{"banana", "ana"},
+	{"mississippi", "issi"},
+	{"mississippi", "ssippi"},
+}
+
+func checkSearch(t *testing.T, source, target string) {
+	t.Helper()
+	index, last := strings.Index(source, target), strings.LastIndex(source, target)
+	if got := StrStr(source, target); got != index {
+		t.Fatalf("StrStr(%q, %q) = %d, want %d", source, target, got, index)
+	}
+	if got := StrRstr(source, target); got != last {
+		t.Fatalf("StrRstr(%q, %q) = %d, want %d", source, target, got, last)
+	}
+	s := NewSearcher(target)
+	if got := s.Index(source); got != index {
+		t.Fatalf("Searcher(%q).Index(%q) = %d, want %d", target, source, got, index)
+	}
+	if got := s.LastIndex(source); got != last {
+		t.Fatalf("Searcher(%q).LastIndex(%q) = %d, want %d", target, source, got, last)
+	}
+	// 同一 Searcher 重复使用
+	if got := s.Index(source); got != index {
+		t.Fatalf("Searcher(%q).Index(%q) again = %d, want %d", target, source, got, index)
+	}
+}
+
+func TestStrStrTable(t *testing.T) {
+	for _, test := range searchTests {
+		checkSearch(t, test.source, test.target)
+	}
+}
+
+func TestStrStrRandom(t *testing.T) {
+	alphabets := []string{"ab", "abc", "a\x80\xff", "\x00\x7f\x80\xfe\xff"}
+	for round := 0; round < 20000; round++ {
+		alphabet := alphabets[round%len(alphabets)]
+		random := func(n int) string {
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = alphabet[rand.Intn(len(alphabet))]
+			}
+			return string(b)
+		}
+		var target string
+		switch round % 4 {
+		case 0:
+			target = random(1)
+		case 1:
+			// 周期模式
+			target = strings.Repeat(random(1+rand.Intn(3)), 1+rand.Intn(5))
+			target += target[:rand.Intn(len(target))]
+		default:
+			target = random(rand.Intn(8))
+		}
+		source := random(rand.Intn(64))
+		if rand.Intn(2) == 0 && len(source) > 0 {
+			at := rand.Intn(len(source))
+			source = source[:at] + target + source[at:]
+		}
+		checkSearch(t, source, target)
+	}
+}
+
+func TestIndexByte(t *testing.T) {
+	for round := 0; round < 5000; round++ {
+		b := make([]byte, rand.Intn(40))
+		for i := range b {
+			b[i] = uint8(rand.Intn(4)) + 0x7e
+		}
+		ch := uint8(rand.Intn(4)) + 0x7e
+		if got, want := indexByte(string(b), ch), strings.IndexByte(string(b), ch); got != want {
+			t.Fatalf("indexByte(%q, %#x) = %d, want %d", b, ch, got, want)
+		}
+		if got, want := lastIndexByte(string(b), ch), strings.LastIndexByte(string(b), ch); got != want {
+			t.Fatalf("lastIndexByte(%q, %#x) = %d, want %d", b, ch, got, want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// table layout:
+//         data block ... | index | footer
+// block:  (key size(uvarint) | key | value size(uvarint) | value) ... | crc32(4)
+// index:  block count(uvarint) | (offset(uvarint) | size(uvarint) | first key size(uvarint) | first key) ... | crc32(4)
+// footer: index offset(8) | index size(8) | items count(8) | magic(8)
+const (
+	tableFooterSize = 32
+	tableMagic      = 0x3154535353494f47 // "GOISSST1"
+	tableBlockSize  = 4096
+)
+
+// TableOptions : WriteTable options
+type TableOptions struct {
+	// BlockSize : data block size target, 0 means 4096
+	BlockSize int
+}
+
+type tableIndex struct {
+	first  interface{}
+	offset int64
+	size   int64
+}
+
+// Table : read-only sorted table, blocks are read on demand
+type Table struct {
+	r        io.ReaderAt
+	compaire func(a, b interface{}) int
+	key      Codec
+	value    Codec
+	index    []tableIndex
+	count    uint64
+}
+
+// TableIterator
+type TableIterator struct {
+	table *Table
+	block int
+	items []RBTpaire
+	pos   int
+	err   error
+}
+
+// WriteTable : write all items of m in key order
+func WriteTable(w io.Writer, m *Map, key, value Codec, opts *TableOptions) error {
+	blockSize := tableBlockSize
+	if opts != nil && opts.BlockSize > 0 {
+		blockSize = opts.BlockSize
+	}
+	bw := bufio.NewWriter(w)
+	var offset int64
+	var block, index []byte
+	var blocks uint64
+	var first []byte
+	flush := func() error {
+		block = appendUint32(block, crc32.ChecksumIEEE(block))
+		if _, err := bw.Write(block); err != nil {
+			return err
+		}
+		index = appendUvarint(index, uint64(offset))
+		index = appendUvarint(index, uint64(len(block)))
+		index = appendUvarint(index, uint64(len(first)))
+		index = append(index, first...)
+		offset += int64(len(block))
+		blocks++
+		block = block[:0]
+		return nil
+	}
+	for it := m.Begin(); !it.IsEnd(); it = it.Next() {
+		p := it.Value()
+		k, err := key.Encode(p.Key())
+		if err != nil {
+			return err
+		}
+		v, err := value.Encode(p.Value)
+		if err != nil {
+			return err
+		}
+		if len(block) == 0 {
+			first = append(first[:0], k...)
+		}
+		block = appendUvarint(block, uint64(len(k)))
+		block = append(block, k...)
+		block = appendUvarint(block, uint64(len(v)))
+		block = append(block, v...)
+		if len(block) >= blockSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(block) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	index = append(appendUvarint(nil, blocks), index...)
+	index = appendUint32(index, crc32.ChecksumIEEE(index))
+	if _, err := bw.Write(index); err != nil {
+		return err
+	}
+	var footer [tableFooterSize]byte
+	binary.LittleEndian.PutUint64(footer[0:], uint64(offset))
+	binary.LittleEndian.PutUint64(footer[8:], uint64(len(index)))
+	binary.LittleEndian.PutUint64(footer[16:], m.Size())
+	binary.LittleEndian.PutUint64(footer[24:], tableMagic)
+	if _, err := bw.Write(footer[:]); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// OpenTable : read footer and index, size is the table size in bytes, nil compaire means OrderedCompaire
+func OpenTable(r io.ReaderAt, size int64, compaire func(a, b interface{}) int, key, value Codec) (*Table, error) {
+	if size < tableFooterSize {
+		return nil, errCorrupt
+	}
+	var footer [tableFooterSize]byte
+	if _, err := r.ReadAt(footer[:], size-tableFooterSize); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(footer[24:]) != tableMagic {
+		return nil, errCorrupt
+	}
+	offset := int64(binary.LittleEndian.Uint64(footer[0:]))
+	length := int64(binary.LittleEndian.Uint64(footer[8:]))
+	if offset < 0 || length < 4 || offset+length != size-tableFooterSize {
+		return nil, errCorrupt
+	}
+	raw, err := readChecked(r, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	if compaire == nil {
+		compaire = OrderedCompaire
+	}
+	t := &Table{r: r, compaire: compaire, key: key, value: value}
+	t.count = binary.LittleEndian.Uint64(footer[16:])
+	blocks, n := binary.Uvarint(raw)
+	if n <= 0 || blocks > uint64(len(raw)) {
+		return nil, errCorrupt
+	}
+	raw = raw[n:]
+	t.index = make([]tableIndex, blocks)
+	for i := range t.index {
+		var fields [3]uint64
+		for j := range fields {
+			if fields[j], n = binary.Uvarint(raw); n <= 0 {
+				return nil, errCorrupt
+			}
+			raw = raw[n:]
+		}
+		if fields[2] > uint64(len(raw)) {
+			return nil, errCorrupt
+		}
+		if t.index[i].first, err = key.Decode(raw[:fields[2]]); err != nil {
+			return nil, err
+		}
+		raw = raw[fields[2]:]
+		t.index[i].offset = int64(fields[0])
+		t.index[i].size = int64(fields[1])
+	}
+	return t, nil
+}
+
+func readChecked(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	if size < 4 {
+		return nil, errCorrupt
+	}
+	raw := make([]byte, size)
+	if _, err := r.ReadAt(raw, offset); err != nil {
+		return nil, err
+	}
+	raw, sum := raw[:size-4], raw[size-4:]
+	if crc32.ChecksumIEEE(raw) != binary.LittleEndian.Uint32(sum) {
+		return nil, errCorrupt
+	}
+	return raw, nil
+}
+
+// Size : items count
+//go:nosplit
+func (t *Table) Size() uint64 {
+	return t.count
+}
+
+// Begin
+func (t *Table) Begin() TableIterator {
+	return t.load(0, 0)
+}
+
+// End
+//go:nosplit
+func (t *Table) End() TableIterator {
+	return TableIterator{table: t, block: len(t.index)}
+}
+
+// LowerBound : first item which key >= key
+func (t *Table) LowerBound(key interface{}) TableIterator {
+	// 最后一个首键 <= key 的块
+	block := sort.Search(len(t.index), func(i int) bool {
+		return t.compaire(t.index[i].first, key) > 0
+	}) - 1
+	if block < 0 {
+		block = 0
+	}
+	it := t.load(block, 0)
+	if it.err != nil || it.IsEnd() {
+		return it
+	}
+	pos := sort.Search(len(it.items), func(i int) bool {
+		return t.compaire(it.items[i].first, key) >= 0
+	})
+	if pos < len(it.items) {
+		it.pos = pos
+		return it
+	}
+	return t.load(block+1, 0)
+}
+
+// Find : item by key, or End
+func (t *Table) Find(key interface{}) TableIterator {
+	it := t.LowerBound(key)
+	if it.err == nil && !it.IsEnd() && t.compaire(it.items[it.pos].first, key) == 0 {
+		return it
+	}
+	end := t.End()
+	end.err = it.err
+	return end
+}
+
+func (t *Table) load(block, pos int) TableIterator {
+	it := TableIterator{table: t, block: block, pos: pos}
+	if block >= len(t.index) {
+		it.block = len(t.index)
+		return it
+	}
+	raw, err := readChecked(t.r, t.index[block].offset, t.index[block].size)
+	if err != nil {
+		it.err = err
+		return it
+	}
+	for len(raw) > 0 {
+		var parts [2][]byte
+		for i := range parts {
+			size, n := binary.Uvarint(raw)
+			if n <= 0 || size > uint64(len(raw)-n) {
+				it.err = errCorrupt
+				return it
+			}
+			parts[i] = raw[n : n+int(size)]
+			raw = raw[n+int(size):]
+		}
+		var p RBTpaire
+		if p.first, err = t.key.Decode(parts[0]); err == nil {
+			p.Value, err = t.value.Decode(parts[1])
+		}
+		if err != nil {
+			it.err = err
+			return it
+		}
+		it.items = append(it.items, p)
+	}
+	return it
+}
+
+// IsEnd : end of table, or read error
+//go:nosplit
+func (it TableIterator) IsEnd() bool {
+	return it.table == nil || it.err != nil || it.block >= len(it.table.index)
+}
+
+// Err : read or decode error
+//go:nosplit
+func (it TableIterator) Err() error {
+	return it.err
+}
+
+// Next : next Iterator
+func (it TableIterator) Next() TableIterator {
+	if it.IsEnd() {
+		return it
+	}
+	if it.pos+1 < len(it.items) {
+		it.pos++
+		return it
+	}
+	return it.table.load(it.block+1, 0)
+}
+
+// Value return item data
+func (it TableIterator) Value() *RBTpaire {
+	if it.IsEnd() {
+		return nil
+	}
+	return &it.items[it.pos]
+}
@@ -136,46 +136,37 @@ func StrRchr(str string, ch uint8) int {
 	return -1
 }
 
-//StrStr : search target from source begin, return first index
+//StrStr : search target from source begin, return first index. Two-Way search, linear time
 //go:nosplit
 func StrStr(source, target string) int {
-	if len(target) == 0 {
+	switch {
+	case len(target) == 0:
 		return 0
-	}
-	matchSize := len(source) - len(target)
-	if matchSize < 0 {
+	case len(target) > len(source):
 		return -1
+	case len(target) == 1:
+		return indexByte(source, target[0])
 	}
-	for off := 0; off <= matchSize; off++ {
-		i := 0
-		for i < len(target) && source[off+i] == target[i] {
-			i++
-		}
-		if i == len(target) {
-			return off
-		}
-	}
-	return -1
+	var t twoWay
+	t.init(target, false)
+	return t.index(source, target, false)
 }
 
-//StrRstr : search target from source end, return first index
+//StrRstr : search target from source end, return first index. Two-Way search, linear time
 //go:nosplit
 func StrRstr(source, target string) int {
-	if len(target) == 0 {
+	switch {
+	case len(target) == 0:
 		return len(source)
-	}
-	matchBegin := len(source) - len(target)
-	if matchBegin < 0 {
+	case len(target) > len(source):
 		return -1
+	case len(target) == 1:
+		return lastIndexByte(source, target[0])
 	}
-	for off := matchBegin; off >= 0; off-- {
-		i := 0
-		for i < len(target) && source[off+i] == target[i] {
-			i++
-		}
-		if i == len(target) {
-			return off
-		}
+	var t twoWay
+	t.init(target, true)
+	if j := t.index(source, target, true); j >= 0 {
+		return len(source) - j - len(target)
 	}
 	return -1
 }
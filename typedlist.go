@@ -0,0 +1,260 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+type typedListNode[T any] struct {
+	pre   *typedListNode[T]
+	nxt   *typedListNode[T]
+	value T
+	owner *TypedList[T]
+	gen   uint32 // 节点每次离开链表加一, 旧迭代器随之失效
+}
+
+//go:nosplit
+func (n *typedListNode[T]) reset() {
+	n.pre = nil
+	n.nxt = nil
+	var zero T
+	n.value = zero
+}
+
+//TypedListIterator : iterator of TypedList
+type TypedListIterator[T any] struct {
+	root *TypedList[T]
+	node *typedListNode[T]
+	gen  uint32
+}
+
+//Set : set the node value
+func (i TypedListIterator[T]) Set(value T) bool {
+	if i.node == nil || !i.Valid() {
+		return false
+	}
+	i.node.value = value
+	return true
+}
+
+//Valid : check iterator valid, false if the node was removed
+//go:nosplit
+func (i TypedListIterator[T]) Valid() bool {
+	if i.root == nil {
+		return false
+	}
+	if i.node == nil {
+		return true
+	}
+	return i.node.owner == i.root && i.node.gen == i.gen
+}
+
+//Destroy iterator
+//go:nosplit
+func (i *TypedListIterator[T]) Destroy() {
+	i.node = nil
+	i.gen = 0
+}
+
+//Remove node from list, return the next iterator, so erase in loop is safe like ListIterator.Remove
+func (i TypedListIterator[T]) Remove() (TypedListIterator[T], T, bool) {
+	if i.node == nil || !i.Valid() {
+		var zero T
+		return i.root.iterator(nil), zero, false
+	}
+	next := i.node.nxt
+	ret := i.node.value
+	i.root.unlink(i.node)
+	i.root.freenode(i.node)
+	return i.root.iterator(next), ret, true
+}
+
+//IsEnd : iterator is End
+//go:nosplit
+func (i TypedListIterator[T]) IsEnd() bool {
+	return i.node == nil
+}
+
+//InsertFront : insert a node front of current
+func (i TypedListIterator[T]) InsertFront(value T) (TypedListIterator[T], bool) {
+	if !i.Valid() || i.node == nil {
+		return i.root.iterator(nil), false
+	}
+	n := i.root.newnode(i.node.pre, i.node, value)
+	i.node.pre = n
+	if n.pre == nil {
+		i.root.first = n
+	} else {
+		n.pre.nxt = n
+	}
+	i.root.count++
+	return i.root.iterator(n), true
+}
+
+//InsertBack : insert a node back of current
+func (i TypedListIterator[T]) InsertBack(value T) (TypedListIterator[T], bool) {
+	if !i.Valid() || i.node == nil {
+		return i.root.iterator(nil), false
+	}
+	n := i.root.newnode(i.node, i.node.nxt, value)
+	i.node.nxt = n
+	if n.nxt == nil {
+		i.root.last = n
+	} else {
+		n.nxt.pre = n
+	}
+	i.root.count++
+	return i.root.iterator(n), true
+}
+
+//Value
+//go:nosplit
+func (i TypedListIterator[T]) Value() (T, bool) {
+
+	if i.Valid() && i.node != nil {
+		return i.node.value, true
+	}
+	var zero T
+	return zero, false
+}
+
+//Back : next node
+//go:nosplit
+func (i TypedListIterator[T]) Back() TypedListIterator[T] {
+
+	if i.Valid() && i.node != nil {
+		return i.root.iterator(i.node.nxt)
+	}
+	return i.root.iterator(nil)
+}
+
+//Front
+//go:nosplit
+func (i TypedListIterator[T]) Front() TypedListIterator[T] {
+
+	if i.Valid() && i.node != nil {
+		return i.root.iterator(i.node.pre)
+	}
+	return i.root.iterator(nil)
+}
+
+//TypedList : list of T, values are stored without interface boxing
+type TypedList[T any] struct {
+	first *typedListNode[T]
+	last  *typedListNode[T]
+	count int
+}
+
+//go:nosplit
+func (l *TypedList[T]) newnode(pre, nxt *typedListNode[T], value T) *typedListNode[T] {
+	return &typedListNode[T]{pre: pre, nxt: nxt, value: value, owner: l}
+}
+
+//go:nosplit
+func (l *TypedList[T]) freenode(n *typedListNode[T]) {
+	n.owner = nil
+	n.gen++
+	n.reset()
+}
+
+//go:nosplit
+func (l *TypedList[T]) iterator(n *typedListNode[T]) TypedListIterator[T] {
+	if n == nil {
+		return TypedListIterator[T]{l, nil, 0}
+	}
+	return TypedListIterator[T]{l, n, n.gen}
+}
+
+//go:nosplit
+func (l *TypedList[T]) unlink(n *typedListNode[T]) {
+	if n.pre == nil {
+		l.first = n.nxt
+	} else {
+		n.pre.nxt = n.nxt
+	}
+	if n.nxt == nil {
+		l.last = n.pre
+	} else {
+		n.nxt.pre = n.pre
+	}
+	n.pre = nil
+	n.nxt = nil
+	l.count--
+}
+
+//Size
+//go:nosplit
+func (l *TypedList[T]) Size() int {
+	return l.count
+}
+
+//Clear
+func (l *TypedList[T]) Clear() {
+	for l.Size() > 0 {
+		l.PopFront()
+	}
+}
+func (l *TypedList[T]) End() TypedListIterator[T] {
+	return l.iterator(nil)
+}
+
+//Front
+//go:nosplit
+func (l *TypedList[T]) Front() TypedListIterator[T] {
+	return l.iterator(l.first)
+}
+
+//Back
+//go:nosplit
+func (l *TypedList[T]) Back() TypedListIterator[T] {
+	return l.iterator(l.last)
+}
+
+//PushBack
+func (l *TypedList[T]) PushBack(value T) TypedListIterator[T] {
+	n := l.newnode(l.last, nil, value)
+	if l.count == 0 {
+		l.first = n
+	} else {
+		l.last.nxt = n
+	}
+	l.last = n
+	l.count++
+	return l.iterator(n)
+}
+
+//PushFront
+func (l *TypedList[T]) PushFront(value T) TypedListIterator[T] {
+	n := l.newnode(nil, l.first, value)
+	if l.count == 0 {
+		l.last = n
+	} else {
+		l.first.pre = n
+	}
+	l.first = n
+	l.count++
+	return l.iterator(n)
+}
+
+//PopFront
+func (l *TypedList[T]) PopFront() (T, bool) {
+	var zero T
+	if l.count == 0 {
+		return zero, false
+	}
+	rnode := l.first
+	ret := rnode.value
+	l.unlink(rnode)
+	l.freenode(rnode)
+	return ret, true
+}
+
+//PopBack
+func (l *TypedList[T]) PopBack() (T, bool) {
+	var zero T
+	if l.count == 0 {
+		return zero, false
+	}
+	rnode := l.last
+	ret := rnode.value
+	l.unlink(rnode)
+	l.freenode(rnode)
+	return ret, true
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+const unrolledChunk = 64
+
+type unrolledNode struct {
+	pre    *unrolledNode
+	nxt    *unrolledNode
+	values []interface{} // cap 为 chunk
+}
+
+// UnrolledList : list storing up to chunk values in each node, so positional access
+// walks Size()/chunk nodes only. Zero UnrolledList uses 64 values a node
+type UnrolledList struct {
+	first *unrolledNode
+	last  *unrolledNode
+	count int
+	chunk int
+}
+
+// NewUnrolledList : chunk is values count of each node, <= 0 means 64
+func NewUnrolledList(chunk int) *UnrolledList {
+	return (&UnrolledList{}).Init(chunk)
+}
+
+// Init : the list constructor
+func (l *UnrolledList) Init(chunk int) *UnrolledList {
+	if chunk <= 0 {
+		chunk = unrolledChunk
+	}
+	l.first = nil
+	l.last = nil
+	l.count = 0
+	l.chunk = chunk
+	return l
+}
+
+// Size : values count
+//go:nosplit
+func (l *UnrolledList) Size() int {
+	return l.count
+}
+
+// Clear
+func (l *UnrolledList) Clear() {
+	l.Init(l.chunk)
+}
+
+func (l *UnrolledList) newnode() *unrolledNode {
+	if l.chunk <= 0 {
+		l.chunk = unrolledChunk
+	}
+	return &unrolledNode{values: make([]interface{}, 0, l.chunk)}
+}
+
+// link : put n back of pre, pre == nil means front
+func (l *UnrolledList) link(n, pre *unrolledNode) {
+	n.pre = pre
+	if pre == nil {
+		n.nxt = l.first
+		l.first = n
+	} else {
+		n.nxt = pre.nxt
+		pre.nxt = n
+	}
+	if n.nxt == nil {
+		l.last = n
+	} else {
+		n.nxt.pre = n
+	}
+}
+
+func (l *UnrolledList) unlink(n *unrolledNode) {
+	if n.pre == nil {
+		l.first = n.nxt
+	} else {
+		n.pre.nxt = n.nxt
+	}
+	if n.nxt == nil {
+		l.last = n.pre
+	} else {
+		n.nxt.pre = n.pre
+	}
+	n.pre = nil
+	n.nxt = nil
+}
+
+// locate : node holding index i and offset in it, walk from the nearer end
+func (l *UnrolledList) locate(i int) (*unrolledNode, int) {
+	if i < l.count/2 {
+		n := l.first
+		for i >= len(n.values) {
+			i -= len(n.values)
+			n = n.nxt
+		}
+		return n, i
+	}
+	n := l.last
+	i = l.count - i
+	for i > len(n.values) {
+		i -= len(n.values)
+		n = n.pre
+	}
+	return n, len(n.values) - i
+}
+
+// At : value at index i
+func (l *UnrolledList) At(i int) (interface{}, bool) {
+	if i < 0 || i >= l.count {
+		return nil, false
+	}
+	n, off := l.locate(i)
+	return n.values[off], true
+}
+
+// Set : replace value at index i
+func (l *UnrolledList) Set(i int, value interface{}) bool {
+	if i < 0 || i >= l.count {
+		return false
+	}
+	n, off := l.locate(i)
+	n.values[off] = value
+	return true
+}
+
+// PushBack
+func (l *UnrolledList) PushBack(value interface{}) {
+	if l.last == nil || len(l.last.values) == cap(l.last.values) {
+		l.link(l.newnode(), l.last)
+	}
+	l.last.values = append(l.last.values, value)
+	l.count++
+}
+
+// PushFront
+func (l *UnrolledList) PushFront(value interface{}) {
+	l.InsertAt(0, value)
+}
+
+// InsertAt : insert value so it is at index i, i == Size() means back
+func (l *UnrolledList) InsertAt(i int, value interface{}) bool {
+	if i < 0 || i > l.count {
+		return false
+	}
+	if i == l.count {
+		l.PushBack(value)
+		return true
+	}
+	n, off := l.locate(i)
+	if len(n.values) == cap(n.values) {
+		// 满了就对半分裂
+		half := len(n.values) / 2
+		right := l.newnode()
+		right.values = append(right.values, n.values[half:]...)
+		clearValues(n.values[half:])
+		n.values = n.values[:half]
+		l.link(right, n)
+		if off > half {
+			n, off = right, off-half
+		}
+	}
+	n.values = append(n.values, nil)
+	copy(n.values[off+1:], n.values[off:])
+	n.values[off] = value
+	l.count++
+	return true
+}
+
+// RemoveAt : remove value at index i
+func (l *UnrolledList) RemoveAt(i int) (interface{}, bool) {
+	if i < 0 || i >= l.count {
+		return nil, false
+	}
+	n, off := l.locate(i)
+	ret := n.values[off]
+	copy(n.values[off:], n.values[off+1:])
+	n.values[len(n.values)-1] = nil
+	n.values = n.values[:len(n.values)-1]
+	l.count--
+	if len(n.values) == 0 {
+		l.unlink(n)
+	} else if nxt := n.nxt; nxt != nil && len(n.values)+len(nxt.values) <= cap(n.values)/2 {
+		// 与后继合并, 免得节点越来越稀
+		n.values = append(n.values, nxt.values...)
+		l.unlink(nxt)
+	}
+	return ret, true
+}
+
+// PopFront
+func (l *UnrolledList) PopFront() (interface{}, bool) {
+	return l.RemoveAt(0)
+}
+
+// PopBack
+func (l *UnrolledList) PopBack() (interface{}, bool) {
+	return l.RemoveAt(l.count - 1)
+}
+
+// Each : walk values in order, if handler return true, then walk end.
+func (l *UnrolledList) Each(handler func(i int, value interface{}) bool) {
+	i := 0
+	for n := l.first; n != nil; n = n.nxt {
+		for _, v := range n.values {
+			if handler(i, v) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+//go:nosplit
+func clearValues(values []interface{}) {
+	for i := range values {
+		values[i] = nil
+	}
+}
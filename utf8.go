@@ -0,0 +1,161 @@
+// Copyright 2021 冯立强 mr.fengliqiang@gmail.com.  All rights reserved.
+
+package goinline
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+//Utf8ToLower
+//go:nosplit
+func Utf8ToLower(r rune) rune {
+	if r < utf8.RuneSelf {
+		return rune(ToLower(uint8(r)))
+	}
+	return unicode.ToLower(r)
+}
+
+//Utf8ToUpper
+//go:nosplit
+func Utf8ToUpper(r rune) rune {
+	if r < utf8.RuneSelf {
+		return rune(ToUpper(uint8(r)))
+	}
+	return unicode.ToUpper(r)
+}
+
+//Utf8Fold : Unicode simple case folding to the smallest rune of the fold orbit, so Utf8Fold('\u212A') == 'K'
+//go:nosplit
+func Utf8Fold(r rune) rune {
+	if r < utf8.RuneSelf {
+		// ASCII 字母轨道中最小者总是大写字母
+		return rune(ToUpper(uint8(r)))
+	}
+	ret := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < ret {
+			ret = f
+		}
+	}
+	return ret
+}
+
+//Utf8IsWhite : Unicode white space
+//go:nosplit
+func Utf8IsWhite(r rune) bool {
+	return unicode.IsSpace(r)
+}
+
+//Utf8Len : rune count
+//go:nosplit
+func Utf8Len(str string) int {
+	return utf8.RuneCountInString(str)
+}
+
+//Utf8Casecmp : compare by simple case folding, return int; a < b => -1; a > b => 1; a == b => 0.
+//Runes are ordered by the lower case of their fold, so ASCII strings compare like StrCasecmp.
+//Invalid bytes compare by byte value
+//go:nosplit
+func Utf8Casecmp(a, b string) int {
+	for len(a) > 0 && len(b) > 0 {
+		ra, sa := rune(a[0]), 1
+		if ra >= utf8.RuneSelf {
+			ra, sa = utf8.DecodeRuneInString(a)
+		}
+		rb, sb := rune(b[0]), 1
+		if rb >= utf8.RuneSelf {
+			rb, sb = utf8.DecodeRuneInString(b)
+		}
+		if sa == 1 && sb == 1 && ra == utf8.RuneError && rb == utf8.RuneError {
+			ra, rb = rune(a[0]), rune(b[0])
+		}
+		if ra != rb {
+			if fa, fb := Utf8ToLower(Utf8Fold(ra)), Utf8ToLower(Utf8Fold(rb)); fa != fb {
+				if fa < fb {
+					return -1
+				}
+				return 1
+			}
+		}
+		a = a[sa:]
+		b = b[sb:]
+	}
+	if len(a) == len(b) {
+		return 0
+	}
+	if len(a) < len(b) {
+		return -1
+	}
+	return 1
+}
+
+//Utf8Chr : byte offset of first r, -1 if not found
+//go:nosplit
+func Utf8Chr(str string, r rune) int {
+	if r >= 0 && r < utf8.RuneSelf {
+		return StrChr(str, uint8(r))
+	}
+	for offset, ch := range str {
+		if ch == r {
+			return offset
+		}
+	}
+	return -1
+}
+
+//Utf8Rchr : byte offset of last r, -1 if not found
+//go:nosplit
+func Utf8Rchr(str string, r rune) int {
+	if r >= 0 && r < utf8.RuneSelf {
+		return StrRchr(str, uint8(r))
+	}
+	for end := len(str); end > 0; {
+		ch, size := utf8.DecodeLastRuneInString(str[:end])
+		end -= size
+		if ch == r {
+			return end
+		}
+	}
+	return -1
+}
+
+//Utf8Trim : remove runes which issep return true from left and right, like StrTrim(source, Utf8IsWhite)
+//go:nosplit
+func Utf8Trim(source string, issep func(rune) bool) string {
+	for len(source) > 0 {
+		ch, size := utf8.DecodeRuneInString(source)
+		if !issep(ch) {
+			break
+		}
+		source = source[size:]
+	}
+	for len(source) > 0 {
+		ch, size := utf8.DecodeLastRuneInString(source)
+		if !issep(ch) {
+			break
+		}
+		source = source[:len(source)-size]
+	}
+	return source
+}
+
+//Utf8Substr : substr by rune index and rune count
+//go:nosplit
+func Utf8Substr(str string, begin, size int) string {
+	if begin < 0 || size <= 0 {
+		return ""
+	}
+	start := len(str)
+	index := 0
+	for offset := range str {
+		if index == begin {
+			start = offset
+		}
+		if index == begin+size {
+			return str[start:offset]
+		}
+		index++
+	}
+	return str[start:]
+}